@@ -2,12 +2,17 @@ package casparcg
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/overlayfox/casparcg-amcp-go"
 	casparTypes "github.com/overlayfox/casparcg-amcp-go/types"
+	"github.com/overlayfox/casparcg-amcp-go/types/commands"
 	"github.com/overlayfox/casparcg-amcp-go/types/responses"
 
 	"github.com/overlayfox/caspaw-cg/src/types"
@@ -22,15 +27,28 @@ type client struct {
 	caspar         *casparcg.Client
 	eventProcessor types.EventProcessor
 
+	capabilities types.ServerCapabilities
+	capMtx       sync.RWMutex
+
+	// batcher is the connection batched commands are sent on, so no other command ends up in a batch
+	batcher  *casparcg.Client
+	batchMtx sync.Mutex
+
+	alive atomic.Bool
+
 	ctx    context.Context
 	cancel context.CancelFunc
 	wg     sync.WaitGroup
 }
 
 func NewClient(ctx context.Context, logger zerolog.Logger, cfg *Config, eventProcessor types.EventProcessor) types.CasparCGClient {
+	return newClient(ctx, logger, cfg, eventProcessor)
+}
+
+func newClient(ctx context.Context, logger zerolog.Logger, cfg *Config, eventProcessor types.EventProcessor) *client {
 	c, cancel := context.WithCancel(ctx)
 	client := &client{
-		logger: logger.With().Str("component", fmt.Sprintf("caspar-client-%s:%d", cfg.Host, cfg.Port)).Logger(),
+		logger: logger.With().Str("component", "caspar-client-"+net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port))).Logger(),
 		cfg:    cfg,
 
 		caspar:         casparcg.NewClient(cfg.Host, cfg.Port),
@@ -49,17 +67,49 @@ func (c *client) Connect() error {
 	if err != nil {
 		return err
 	}
+	c.alive.Store(true)
+	c.detectCapabilities()
 	return nil
 }
 
+// detectCapabilities queries the server version and rebuilds the capability set from it.
+// If the version can not be determined every capability is assumed to be available.
+func (c *client) detectCapabilities() {
+	version, err := c.caspar.Query().Version().Server()
+	if err != nil {
+		c.logger.Warn().Err(err).Msg("Failed to query CasparCG server version, assuming all capabilities")
+	}
+
+	capabilities, err := types.NewServerCapabilities(version)
+	if err != nil {
+		c.logger.Warn().Err(err).Msg("Failed to detect CasparCG server capabilities, assuming all capabilities")
+	} else {
+		c.logger.Info().Str("version", capabilities.Version).Msgf("Detected CasparCG server capabilities: %v", capabilities.Capabilities)
+	}
+
+	c.capMtx.Lock()
+	c.capabilities = capabilities
+	c.capMtx.Unlock()
+}
+
+func (c *client) GetCapabilities() types.ServerCapabilities {
+	c.capMtx.RLock()
+	defer c.capMtx.RUnlock()
+	return c.capabilities
+}
+
 func (c *client) GetTemplates() ([]string, error) {
-	return c.caspar.Query().TLS(new(string))
+	templates, err := c.caspar.Query().TLS(new(string))
+	if err != nil {
+		return nil, c.wrapMediaScannerError(err)
+	}
+	return templates, nil
 }
 
 func (c *client) GetMedia() ([]string, error) {
 	media, err := c.caspar.Query().CLS(new(string))
 	if err != nil {
-		return nil, err
+		return nil, c.wrapMediaScannerError(err)
 	}
 	result := make([]string, len(media))
 	for i, m := range media {
@@ -69,7 +119,21 @@ func (c *client) GetMedia() ([]string, error) {
 }
 
 func (c *client) GetMediaInfo(filename string) (responses.CINF, error) {
-	return c.caspar.Query().CINF(filename)
+	info, err := c.caspar.Query().CINF(filename)
+	if err != nil {
+		return responses.CINF{}, c.wrapMediaScannerError(err)
+	}
+	return info, nil
+}
+
+// wrapMediaScannerError points at the media scanner for failed CLS/TLS/CINF queries on servers
+// that no longer serve them themselves, as a missing scanner is the most common cause.
+func (c *client) wrapMediaScannerError(err error) error {
+	capabilities := c.GetCapabilities()
+	if capabilities.Detected && capabilities.Has(types.CapabilityMediaScanner) {
+		return fmt.Errorf("%w (CasparCG %s relies on the media scanner for this, is it running?)", err, capabilities.Version)
+	}
+	return err
 }
 
 func (c *client) AddCGData(template string, layer int, channels []int, data map[string]any, sizing types.Sizing, delay time.Duration) error {
@@ -88,19 +152,31 @@ func (c *client) AddCGData(template string, layer int, channels []int, data map[
 	}
 	c.logger.Debug().Msgf("CGAdd json data for template '%s': %+v", template, jsonStr)
 
+	if err := c.GetCapabilities().Require(types.CapabilityInfoChannels); err != nil {
+		return fmt.Errorf("can not determine channel resolution for sizing: %w", err)
+	}
 	info, err := c.caspar.Query().Info().Generic()
 	if err != nil {
 		return err
 	}
+	if len(info) == 0 {
+		return errors.New("can not determine channel resolution for sizing: server reported no channels")
+	}
 	res, err := VideoModeToResolution(info[0].VideoMode)
 	if err != nil {
 		return err
 	}
-	for _, channel := range channels {
-		c.logger.Debug().Msgf("Setting mixer for template '%s' on layer %d, channel %d with sizing: %+v and resolution: %+v", template, layer, channel, sizing, res)
-		if err = c.caspar.Mixer().Channel(channel).Layer(layer).SetFill(sizing.GetCasparMixerParams(res)); err != nil {
-			return err
+	err = c.batch(func(caspar *casparcg.Client) error {
+		for _, channel := range channels {
+			c.logger.Debug().Msgf("Setting mixer for template '%s' on layer %d, channel %d with sizing: %+v and resolution: %+v", template, layer, channel, sizing, res)
+			if err := caspar.Mixer().Channel(channel).Layer(layer).SetFill(sizing.GetCasparMixerParams(res)); err != nil {
+				return err
+			}
 		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
 	if delay > 0 {
@@ -112,12 +188,14 @@ func (c *client) AddCGData(template string, layer int, channels []int, data map[
 		}
 	}
 
-	for _, channel := range channels {
-		if err := c.caspar.CG().Channel(channel).Layer(layer).CGLayer(1).Add(params); err != nil {
-			return err
+	return c.batch(func(caspar *casparcg.Client) error {
+		for _, channel := range channels {
+			if err := caspar.CG().Channel(channel).Layer(layer).CGLayer(1).Add(params); err != nil {
+				return err
+			}
 		}
-	}
-	return nil
+		return nil
+	})
 }
 
 func (c *client) StopCGData(template string, layer int, channels []int, delay time.Duration) error {
@@ -132,12 +210,14 @@ func (c *client) StopCGData(template string, layer int, channels []int, delay ti
 		}
 	}
 
-	for _, channel := range channels {
-		if err := c.caspar.CG().Channel(channel).Layer(layer).CGLayer(1).Stop(); err != nil {
-			return err
+	return c.batch(func(caspar *casparcg.Client) error {
+		for _, channel := range channels {
+			if err := caspar.CG().Channel(channel).Layer(layer).CGLayer(1).Stop(); err != nil {
+				return err
+			}
 		}
-	}
-	return nil
+		return nil
+	})
 	// TODO: Once the information is available via the CasparCG-AMCP-Go library, we should wait as long as the outplay time of the template is reporting
 	// select {
 	// case <-time.After(6000 * time.Millisecond):
@@ -160,12 +240,14 @@ func (c *client) NextCGData(template string, layer int, channels []int, delay ti
 		}
 	}
 
-	for _, channel := range channels {
-		if err := c.caspar.CG().Channel(channel).Layer(layer).CGLayer(1).Next(); err != nil {
-			return err
+	return c.batch(func(caspar *casparcg.Client) error {
+		for _, channel := range channels {
+			if err := caspar.CG().Channel(channel).Layer(layer).CGLayer(1).Next(); err != nil {
+				return err
+			}
 		}
-	}
-	return nil
+		return nil
+	})
 }
 
 func (c *client) UpdateCGData(template string, layer int, channels []int, data map[string]any) error {
@@ -177,12 +259,25 @@ func (c *client) UpdateCGData(template string, layer int, channels []int, data m
 		return err
 	}
 
-	for _, channel := range channels {
-		if err := c.caspar.CG().Channel(channel).Layer(layer).CGLayer(1).Update(jsonStr); err != nil {
-			return err
+	return c.batch(func(caspar *casparcg.Client) error {
+		for _, channel := range channels {
+			if err := caspar.CG().Channel(channel).Layer(layer).CGLayer(1).Update(jsonStr); err != nil {
+				return err
+			}
 		}
+		return nil
+	})
+}
+
+// GetTemplateInfo returns what CG INFO reports about the template on the layer of channel
+func (c *client) GetTemplateInfo(channel, layer int) ([]string, error) {
+	if err := c.GetCapabilities().Require(types.CapabilityCGInfo); err != nil {
+		return nil, err
 	}
-	return nil
+	cgLayer := 1
+	return c.caspar.Send(commands.TemplateCGInfo{
+		CGCommand: commands.CGCommand{VideoChannel: channel, Layer: &layer, CgLayer: &cgLayer},
+	})
 }
 
 func (c *client) PlayMedia(filename string, layer int, channels []int, loop bool, delay time.Duration) error {
@@ -201,14 +296,109 @@ func (c *client) PlayMedia(filename string, layer int, channels []int, loop bool
 		loopParams := []string{"LOOP"}
 		params.Parameters = &loopParams
 	}
-	for _, channel := range channels {
-		if err := c.caspar.Layer().Channel(channel).Layer(layer).Play(params); err != nil {
+	return c.play(params, layer, channels)
+}
+
+func (c *client) PlayProducer(producer types.Producer, layer int, channels []int, delay time.Duration) error {
+	c.logger.Debug().Msgf("Playing %s producer '%s' on layer %d, channels %v with delay: %v", producer.Kind, producer.Clip, layer, channels, delay)
+
+	if delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-c.ctx.Done():
+			return c.ctx.Err()
+		}
+	}
+
+	if producer.Kind == types.ProducerKindHTML {
+		if err := c.GetCapabilities().Require(types.CapabilityHTML); err != nil {
 			return err
 		}
 	}
+
+	params := casparTypes.LayerPlay{ClipName: &producer.Clip}
+	if len(producer.Parameters) > 0 {
+		producerParams := producer.Parameters
+		params.Parameters = &producerParams
+	}
+	return c.play(params, layer, channels)
+}
+
+// play sends a PLAY command with the given params to the layer on every channel
+func (c *client) play(params casparTypes.LayerPlay, layer int, channels []int) error {
+	return c.batch(func(caspar *casparcg.Client) error {
+		for _, channel := range channels {
+			if err := caspar.Layer().Channel(channel).Layer(layer).Play(params); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// batch runs send with the commands of all channels of a take. Servers known to support BEGIN/COMMIT
+// receive them as one batch on a connection of their own, so every channel takes on the same frame.
+// Other servers receive them one by one.
+func (c *client) batch(send func(caspar *casparcg.Client) error) error {
+	capabilities := c.GetCapabilities()
+	if !capabilities.Detected || !capabilities.Has(types.CapabilityBatching) {
+		return send(c.caspar)
+	}
+
+	c.batchMtx.Lock()
+	defer c.batchMtx.Unlock()
+
+	if err := c.beginBatch(); err != nil {
+		return err
+	}
+	if err := send(c.batcher); err != nil {
+		if _, discardErr := c.batcher.Send(amcpCommand("DISCARD")); discardErr != nil {
+			c.closeBatcher()
+		}
+		return err
+	}
+	if _, err := c.batcher.Send(amcpCommand("COMMIT")); err != nil {
+		c.closeBatcher()
+		return fmt.Errorf("failed to commit batch: %w", err)
+	}
 	return nil
 }
 
+// beginBatch sends BEGIN, reopening the batch connection once if it dropped since the last batch.
+// The caller must hold c.batchMtx.
+func (c *client) beginBatch() error {
+	var err error
+	for range 2 {
+		if c.batcher == nil {
+			batcher := casparcg.NewClient(c.cfg.Host, c.cfg.Port)
+			if err := batcher.Connect(c.ctx); err != nil {
+				return fmt.Errorf("failed to open batch connection: %w", err)
+			}
+			c.batcher = batcher
+		}
+		if _, err = c.batcher.Send(amcpCommand("BEGIN")); err == nil {
+			return nil
+		}
+		c.closeBatcher()
+	}
+	return fmt.Errorf("failed to begin batch: %w", err)
+}
+
+// closeBatcher drops the batch connection, the next batch reconnects. The caller must hold c.batchMtx.
+func (c *client) closeBatcher() {
+	if c.batcher != nil {
+		c.batcher.Close()
+		c.batcher = nil
+	}
+}
+
+// amcpCommand is a command without parameters the library has no builder for
+type amcpCommand string
+
+func (a amcpCommand) String() string {
+	return string(a)
+}
+
 func (c *client) StopMedia(layer int, channels []int, delay time.Duration) error {
 	c.logger.Debug().Msgf("Stopping media on layer %d, channels %v with delay: %v", layer, channels, delay)
 
@@ -220,12 +410,14 @@ func (c *client) StopMedia(layer int, channels []int, delay time.Duration) error
 		}
 	}
 
-	for _, channel := range channels {
-		if err := c.caspar.Layer().Channel(channel).Layer(layer).Stop(); err != nil {
-			return err
+	return c.batch(func(caspar *casparcg.Client) error {
+		for _, channel := range channels {
+			if err := caspar.Layer().Channel(channel).Layer(layer).Stop(); err != nil {
+				return err
+			}
 		}
-	}
-	return nil
+		return nil
+	})
 }
 
 func (c *client) ClearChannels(channels []int) {
@@ -269,6 +461,7 @@ func (c *client) keepAlive() {
 					err := c.caspar.Connect(c.ctx)
 					if err == nil {
 						c.logger.Debug().Msg("Reconnected to CasparCG server")
+						c.detectCapabilities() // the server may have been swapped for another version
 					}
 				} else {
 					sentDebugMessage = false
@@ -278,6 +471,7 @@ func (c *client) keepAlive() {
 						IsAlive: true,
 					}
 				}
+				c.alive.Store(event.IsAlive)
 				c.eventProcessor.Push(event)
 			case <-c.ctx.Done():
 				return
@@ -290,4 +484,8 @@ func (c *client) Close() {
 	c.cancel()
 	c.wg.Wait()
 	c.caspar.Close()
+
+	c.batchMtx.Lock()
+	c.closeBatcher()
+	c.batchMtx.Unlock()
 }
@@ -0,0 +1,176 @@
+package casparcg
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	"github.com/overlayfox/caspaw-cg/src/events"
+	"github.com/overlayfox/caspaw-cg/src/types"
+)
+
+// fakeServer answers every AMCP command with success and records them per connection
+type fakeServer struct {
+	listener net.Listener
+	version  string
+
+	commands [][]string
+	mtx      sync.Mutex
+}
+
+func newFakeServer(t *testing.T, version string) *fakeServer {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeServer{listener: listener, version: version}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			s.mtx.Lock()
+			s.commands = append(s.commands, nil)
+			index := len(s.commands) - 1
+			s.mtx.Unlock()
+			go s.serve(conn, index)
+		}
+	}()
+	return s
+}
+
+func (s *fakeServer) serve(conn net.Conn, index int) {
+	defer conn.Close()
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		command := strings.TrimSpace(scanner.Text())
+		s.mtx.Lock()
+		s.commands[index] = append(s.commands[index], command)
+		s.mtx.Unlock()
+
+		var reply string
+		switch {
+		case command == "PING":
+			reply = "PONG\r\n"
+		case command == "VERSION SERVER":
+			reply = "201 VERSION OK\r\n" + s.version + "\r\n"
+		default:
+			reply = "202 " + strings.Fields(command)[0] + " OK\r\n"
+		}
+		if _, err := conn.Write([]byte(reply)); err != nil {
+			return
+		}
+	}
+}
+
+func (s *fakeServer) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+// received returns the commands of every connection starting with prefix
+func (s *fakeServer) received(prefix ...string) [][]string {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	result := make([][]string, 0, len(s.commands))
+	for _, commands := range s.commands {
+		var matching []string
+		for _, command := range commands {
+			if slices.ContainsFunc(prefix, func(p string) bool { return strings.HasPrefix(command, p) }) {
+				matching = append(matching, command)
+			}
+		}
+		result = append(result, matching)
+	}
+	return result
+}
+
+func TestClientBatch(t *testing.T) {
+	tests := []struct {
+		version string
+		want    [][]string
+	}{
+		{
+			version: "2.3.3 LTS",
+			want:    [][]string{{"CG 1-20 NEXT 1", "CG 2-20 NEXT 1"}},
+		},
+		{
+			version: "2.4.0 Stable",
+			want:    [][]string{nil, {"BEGIN", "CG 1-20 NEXT 1", "CG 2-20 NEXT 1", "COMMIT"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			server := newFakeServer(t, tt.version)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			processor := events.NewProcessor(ctx, zerolog.Nop())
+			defer processor.Close()
+
+			c := newClient(ctx, zerolog.Nop(), &Config{Host: "127.0.0.1", Port: server.port()}, processor)
+			if err := c.Connect(); err != nil {
+				t.Fatal(err)
+			}
+			defer c.Close()
+			if !c.GetCapabilities().Detected {
+				t.Fatalf("capabilities of %s not detected", tt.version)
+			}
+
+			if err := c.NextCGData("lower-third", 20, []int{1, 2}, 0); err != nil {
+				t.Fatal(err)
+			}
+			if got := server.received("BEGIN", "CG", "COMMIT"); !slices.EqualFunc(got, tt.want, slices.Equal) {
+				t.Errorf("server received %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientTemplateInfo(t *testing.T) {
+	tests := []struct {
+		version string
+		wantErr bool
+	}{
+		{version: "2.3.3 LTS"},
+		{version: "2.5.0 Stable", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			server := newFakeServer(t, tt.version)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			processor := events.NewProcessor(ctx, zerolog.Nop())
+			defer processor.Close()
+
+			c := newClient(ctx, zerolog.Nop(), &Config{Host: "127.0.0.1", Port: server.port()}, processor)
+			if err := c.Connect(); err != nil {
+				t.Fatal(err)
+			}
+			defer c.Close()
+
+			_, err := c.GetTemplateInfo(1, 20)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetTemplateInfo() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			want := []string{"CG 1-20 INFO 1"}
+			if tt.wantErr {
+				want = nil
+			}
+			if got := server.received("CG")[0]; !slices.Equal(got, want) {
+				t.Errorf("server received %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+var _ types.CasparCGClient = (*client)(nil)
@@ -4,20 +4,33 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"net/netip"
+	"strings"
 )
 
 type Config struct {
 	Debug bool   `mapstructure:"debug"`
 	Host  string `mapstructure:"host"`
 	Port  int    `mapstructure:"port"`
+
+	// Backup is an optional hot-spare server every command is mirrored to
+	Backup *Config `mapstructure:"backup"`
+	// FailoverThreshold is the number of consecutive failed keep-alives before switching servers
+	FailoverThreshold int `mapstructure:"failover_threshold"`
+	// AutoFailback switches back to the primary once it passed FailoverThreshold keep-alives again
+	AutoFailback bool `mapstructure:"auto_failback"`
+
+	// DiscoverySubnet is the default subnet probed for AMCP listeners, e.g. "192.168.1.0/24"
+	DiscoverySubnet string `mapstructure:"discovery_subnet"`
 }
 
 func (c *Config) Validate() error {
 	if c.Host == "" {
 		return errors.New("host is required")
 	}
-	if net.ParseIP(c.Host) == nil {
-		return fmt.Errorf("invalid host: %s", c.Host)
+	c.Host = strings.TrimSuffix(strings.TrimPrefix(c.Host, "["), "]") // allow bracketed IPv6 literals such as "[::1]"
+	if net.ParseIP(c.Host) == nil && !isValidHostname(c.Host) {
+		return fmt.Errorf("invalid host: %s (expected an IP address or hostname)", c.Host)
 	}
 
 	if c.Port == 0 {
@@ -27,6 +40,27 @@ func (c *Config) Validate() error {
 		return errors.New("port must be between 1 and 65535")
 	}
 
+	if c.DiscoverySubnet != "" {
+		if _, err := netip.ParsePrefix(c.DiscoverySubnet); err != nil {
+			return fmt.Errorf("invalid discovery_subnet: %w", err)
+		}
+	}
+
+	if c.Backup != nil {
+		if c.Backup.Backup != nil {
+			return errors.New("backup can not have a backup of its own")
+		}
+		if err := c.Backup.Validate(); err != nil {
+			return fmt.Errorf("backup: %w", err)
+		}
+		if c.Backup.Host == c.Host && c.Backup.Port == c.Port {
+			return errors.New("backup must be a different server than the primary")
+		}
+		if c.FailoverThreshold < 1 {
+			return errors.New("failover_threshold must be at least 1")
+		}
+	}
+
 	return nil
 }
 
@@ -35,6 +69,30 @@ func (c *Config) Default() {
 		Host:  "127.0.0.1",
 		Port:  5250,
 		Debug: false,
+
+		FailoverThreshold: 3,
+		AutoFailback:      false,
 	}
 	*c = def
 }
+
+// isValidHostname reports whether host is a valid RFC 1123 DNS name, resolution is left to connect time
+func isValidHostname(host string) bool {
+	host = strings.TrimSuffix(host, ".")
+	if host == "" || len(host) > 253 {
+		return false
+	}
+
+	for label := range strings.SplitSeq(host, ".") {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, ch := range label {
+			isAlphaNum := (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || (ch >= '0' && ch <= '9')
+			if !isAlphaNum && ch != '-' {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,65 @@
+package casparcg
+
+import (
+	"time"
+
+	casparTypes "github.com/overlayfox/casparcg-amcp-go/types"
+	"github.com/overlayfox/casparcg-amcp-go/types/commands"
+
+	"github.com/overlayfox/caspaw-cg/src/types"
+)
+
+// GetDiagnostics collects version, system, path, config and channel information from the server.
+// Only a failing VERSION SERVER fails the collection, as that means the server is not reachable.
+func (c *client) GetDiagnostics() (types.ServerDiagnostics, error) {
+	c.logger.Debug().Msg("Collecting server diagnostics")
+
+	diag := types.ServerDiagnostics{
+		Host:        c.cfg.Host,
+		Port:        c.cfg.Port,
+		CollectedAt: time.Now(),
+		Errors:      make(map[string]string),
+	}
+
+	var err error
+	diag.Version.Server, err = c.caspar.Query().Version().Server()
+	if err != nil {
+		return types.ServerDiagnostics{}, err
+	}
+
+	record := func(section string, err error) {
+		if err != nil {
+			c.logger.Debug().Err(err).Str("section", section).Msg("Failed to collect diagnostics section")
+			diag.Errors[section] = err.Error()
+		}
+	}
+
+	capabilities := c.GetCapabilities()
+	diag.Capabilities = capabilities
+	if capabilities.Has(types.CapabilityFlash) {
+		diag.Version.Flash, err = c.caspar.Query().Version().Flash()
+		record("VERSION FLASH", err)
+	}
+	if capabilities.Has(types.CapabilityHTML) {
+		diag.Version.HTML, err = c.caspar.Query().Version().CEF()
+		record("VERSION CEF", err)
+	}
+
+	// the library parses INFO SYSTEM as channel info, which does not match what the server returns
+	diag.System, err = c.caspar.Send(commands.QueryInfo{Component: casparTypes.InfoComponentSystem})
+	record("INFO SYSTEM", err)
+
+	diag.Paths, err = c.caspar.Query().Info().Paths()
+	record("INFO PATHS", err)
+
+	diag.Config, err = c.caspar.Query().Info().Config()
+	record("INFO CONFIG", err)
+
+	diag.Channels, err = c.caspar.Query().Info().Generic()
+	record("INFO", err)
+
+	if len(diag.Errors) == 0 {
+		diag.Errors = nil
+	}
+	return diag, nil
+}
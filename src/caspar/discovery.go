@@ -0,0 +1,122 @@
+package casparcg
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/overlayfox/caspaw-cg/src/types"
+)
+
+const (
+	// maxDiscoveryHosts caps the subnet size so a mistyped prefix doesn't probe half the internet
+	maxDiscoveryHosts = 1024
+	// discoveryWorkers is the number of hosts probed in parallel
+	discoveryWorkers = 64
+)
+
+// Discover probes every host in subnet for an AMCP listener on port and reports the version of each one found.
+// Hosts that don't answer within timeout, or answer with something that isn't AMCP, are skipped.
+func Discover(ctx context.Context, subnet string, port int, timeout time.Duration) ([]types.DiscoveredCasparCGServer, error) {
+	prefix, err := netip.ParsePrefix(subnet)
+	if err != nil {
+		return nil, fmt.Errorf("invalid subnet: %w", err)
+	}
+	prefix = prefix.Masked()
+
+	hostBits := prefix.Addr().BitLen() - prefix.Bits()
+	if hostBits >= 31 || 1<<hostBits > maxDiscoveryHosts {
+		return nil, fmt.Errorf("subnet %s is too large to probe, at most %d hosts are allowed", subnet, maxDiscoveryHosts)
+	}
+
+	addrs := make(chan netip.Addr)
+	go func() {
+		defer close(addrs)
+		for addr := prefix.Addr(); prefix.Contains(addr); addr = addr.Next() {
+			select {
+			case addrs <- addr:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var (
+		found []types.DiscoveredCasparCGServer
+		mtx   sync.Mutex
+		wg    sync.WaitGroup
+	)
+	for range discoveryWorkers {
+		wg.Go(func() {
+			for addr := range addrs {
+				server, ok := probe(ctx, addr.String(), port, timeout)
+				if !ok {
+					continue
+				}
+				mtx.Lock()
+				found = append(found, server)
+				mtx.Unlock()
+			}
+		})
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(found, func(a, b types.DiscoveredCasparCGServer) int {
+		return netip.MustParseAddr(a.Host).Compare(netip.MustParseAddr(b.Host))
+	})
+	return found, nil
+}
+
+// probe sends a VERSION command to host:port and parses the "201 VERSION OK" response.
+// It uses a raw connection with deadlines, as arbitrary services may be listening on the port.
+func probe(ctx context.Context, host string, port int, timeout time.Duration) (types.DiscoveredCasparCGServer, bool) {
+	dialCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var d net.Dialer
+	conn, err := d.DialContext(dialCtx, "tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+	if err != nil {
+		return types.DiscoveredCasparCGServer{}, false
+	}
+	defer conn.Close()
+
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return types.DiscoveredCasparCGServer{}, false
+	}
+	if _, err := conn.Write([]byte("VERSION\r\n")); err != nil {
+		return types.DiscoveredCasparCGServer{}, false
+	}
+
+	reader := bufio.NewReader(conn)
+	status, err := reader.ReadString('\n')
+	if err != nil || !strings.HasPrefix(strings.TrimSpace(status), "201") {
+		return types.DiscoveredCasparCGServer{}, false
+	}
+	version, err := reader.ReadString('\n')
+	if err != nil {
+		return types.DiscoveredCasparCGServer{}, false
+	}
+
+	server := types.DiscoveredCasparCGServer{
+		Host:    host,
+		Port:    port,
+		Version: strings.TrimSpace(version),
+	}
+	lookupCtx, cancelLookup := context.WithTimeout(ctx, timeout)
+	defer cancelLookup()
+	if names, err := net.DefaultResolver.LookupAddr(lookupCtx, host); err == nil && len(names) > 0 {
+		server.Hostname = strings.TrimSuffix(names[0], ".")
+	}
+	return server, true
+}
@@ -0,0 +1,300 @@
+package casparcg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/overlayfox/casparcg-amcp-go/types/responses"
+	"github.com/rs/zerolog"
+
+	"github.com/overlayfox/caspaw-cg/src/types"
+)
+
+// redundantClient mirrors every command to a primary and a backup server and
+// switches the active server when the primary stops answering keep-alives.
+type redundantClient struct {
+	logger zerolog.Logger
+	cfg    *Config
+
+	primary        *client
+	backup         *client
+	eventProcessor types.EventProcessor
+
+	active types.CasparCGServerRole
+	mtx    sync.RWMutex
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewRedundantClient(ctx context.Context, logger zerolog.Logger, cfg *Config, eventProcessor types.EventProcessor) types.RedundantCasparCGClient {
+	c, cancel := context.WithCancel(ctx)
+	return &redundantClient{
+		logger: logger.With().Str("component", "caspar-redundant-client-"+net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port))).Logger(),
+		cfg:    cfg,
+
+		primary:        newClient(c, logger, cfg, eventProcessor),
+		backup:         newClient(c, logger, cfg.Backup, eventProcessor),
+		eventProcessor: eventProcessor,
+
+		active: types.CasparCGServerRolePrimary,
+
+		ctx:    c,
+		cancel: cancel,
+	}
+}
+
+// Connect connects to both servers, it only fails if neither server is reachable
+func (r *redundantClient) Connect() error {
+	defer r.monitor()
+
+	primaryErr := r.primary.Connect()
+	if primaryErr != nil {
+		r.logger.Warn().Err(primaryErr).Msg("Failed to connect to primary CasparCG server")
+	}
+	backupErr := r.backup.Connect()
+	if backupErr != nil {
+		r.logger.Warn().Err(backupErr).Msg("Failed to connect to backup CasparCG server")
+	}
+
+	if primaryErr != nil && backupErr != nil {
+		return errors.Join(primaryErr, backupErr)
+	}
+	if primaryErr != nil {
+		r.setActive(types.CasparCGServerRoleBackup)
+	} else {
+		r.setActive(types.CasparCGServerRolePrimary)
+	}
+	return nil
+}
+
+func (r *redundantClient) GetActive() types.CasparCGServerRole {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+	return r.active
+}
+
+func (r *redundantClient) SetActive(role types.CasparCGServerRole) error {
+	switch role {
+	case types.CasparCGServerRolePrimary, types.CasparCGServerRoleBackup:
+	default:
+		return fmt.Errorf("unknown server role: %s", role)
+	}
+	r.logger.Info().Str("role", string(role)).Msg("Manually switching active CasparCG server")
+	r.setActive(role)
+	return nil
+}
+
+// setActive switches the active server and pushes the new state for the tally
+func (r *redundantClient) setActive(role types.CasparCGServerRole) {
+	r.mtx.Lock()
+	r.active = role
+	r.mtx.Unlock()
+
+	c := r.clientFor(role)
+	event := types.CasparCGActiveServer{
+		Host: c.cfg.Host,
+		Port: c.cfg.Port,
+		Role: role,
+	}
+	if err := r.eventProcessor.Push(event); err != nil {
+		r.logger.Error().Err(err).Msg("Failed to emit active server event")
+	}
+}
+
+func (r *redundantClient) clientFor(role types.CasparCGServerRole) *client {
+	if role == types.CasparCGServerRoleBackup {
+		return r.backup
+	}
+	return r.primary
+}
+
+func (r *redundantClient) activeClient() *client {
+	return r.clientFor(r.GetActive())
+}
+
+// monitor fails over to the backup once the primary missed FailoverThreshold keep-alives in a row,
+// and fails back once the primary answered as many again if AutoFailback is enabled.
+func (r *redundantClient) monitor() {
+	r.wg.Go(func() {
+		ticker := time.NewTicker(1 * time.Second)
+		defer ticker.Stop()
+
+		failed, recovered := 0, 0
+		for {
+			select {
+			case <-ticker.C:
+				r.check(&failed, &recovered)
+			case <-r.ctx.Done():
+				return
+			}
+		}
+	})
+}
+
+// check counts the keep-alive of the primary and switches servers once a count reached the threshold
+func (r *redundantClient) check(failed, recovered *int) {
+	if r.primary.alive.Load() {
+		*failed = 0
+		*recovered++
+	} else {
+		*recovered = 0
+		*failed++
+	}
+
+	switch r.GetActive() {
+	case types.CasparCGServerRolePrimary:
+		if *failed >= r.cfg.FailoverThreshold && r.backup.alive.Load() {
+			r.logger.Warn().Int("missedKeepAlives", *failed).Msg("Primary CasparCG server is down, failing over to backup")
+			r.setActive(types.CasparCGServerRoleBackup)
+		}
+	case types.CasparCGServerRoleBackup:
+		// the primary may have recovered before a failover landed, so its count can already be past the threshold
+		if r.cfg.AutoFailback && *recovered >= r.cfg.FailoverThreshold {
+			r.logger.Info().Msg("Primary CasparCG server is back, failing back from backup")
+			r.setActive(types.CasparCGServerRolePrimary)
+			*recovered = 0
+		}
+	}
+}
+
+// mirror runs fn on both servers in parallel and returns the error of the active server as soon as it
+// answered, a slow or hung standby never holds up the live server. The standby finishes in the background,
+// a command that only failed on one of two reachable servers is reported as a divergence.
+func (r *redundantClient) mirror(command string, fn func(c *client) error) error {
+	activeRole := r.GetActive()
+	standbyRole := types.CasparCGServerRoleBackup
+	if activeRole == types.CasparCGServerRoleBackup {
+		standbyRole = types.CasparCGServerRolePrimary
+	}
+
+	activeDone := make(chan error, 1)
+	standbyDone := make(chan error, 1)
+	go func() { activeDone <- fn(r.clientFor(activeRole)) }()
+	go func() { standbyDone <- fn(r.clientFor(standbyRole)) }()
+
+	activeErr := <-activeDone
+	// not tracked by r.wg, closing the clients ends a command still pending on the standby
+	go func() {
+		standbyErr := <-standbyDone
+		switch {
+		case activeErr != nil && standbyErr == nil:
+			r.reportDivergence(command, activeRole, activeErr)
+		case activeErr == nil && standbyErr != nil:
+			r.reportDivergence(command, standbyRole, standbyErr)
+		}
+	}()
+	return activeErr
+}
+
+func (r *redundantClient) reportDivergence(command string, role types.CasparCGServerRole, err error) {
+	c := r.clientFor(role)
+	if !c.alive.Load() {
+		return // the keep-alive tally already shows the server as down
+	}
+
+	r.logger.Warn().Err(err).Str("role", string(role)).Msgf("Command '%s' diverged between primary and backup", command)
+	event := types.CasparCGDivergence{
+		Command: command,
+		Host:    c.cfg.Host,
+		Port:    c.cfg.Port,
+		Role:    role,
+		Error:   err.Error(),
+	}
+	if err := r.eventProcessor.Push(event); err != nil {
+		r.logger.Error().Err(err).Msg("Failed to emit divergence event")
+	}
+}
+
+func (r *redundantClient) GetCapabilities() types.ServerCapabilities {
+	return r.activeClient().GetCapabilities()
+}
+
+func (r *redundantClient) GetTemplates() ([]string, error) {
+	return r.activeClient().GetTemplates()
+}
+
+func (r *redundantClient) GetMedia() ([]string, error) {
+	return r.activeClient().GetMedia()
+}
+
+func (r *redundantClient) GetMediaInfo(filename string) (responses.CINF, error) {
+	return r.activeClient().GetMediaInfo(filename)
+}
+
+func (r *redundantClient) GetDiagnostics() (types.ServerDiagnostics, error) {
+	return r.activeClient().GetDiagnostics()
+}
+
+func (r *redundantClient) GetTemplateInfo(channel, layer int) ([]string, error) {
+	return r.activeClient().GetTemplateInfo(channel, layer)
+}
+
+func (r *redundantClient) AddCGData(template string, layer int, channels []int, data map[string]any, sizing types.Sizing, delay time.Duration) error {
+	return r.mirror("CG ADD", func(c *client) error {
+		return c.AddCGData(template, layer, channels, data, sizing, delay)
+	})
+}
+
+func (r *redundantClient) StopCGData(template string, layer int, channels []int, delay time.Duration) error {
+	return r.mirror("CG STOP", func(c *client) error {
+		return c.StopCGData(template, layer, channels, delay)
+	})
+}
+
+func (r *redundantClient) NextCGData(template string, layer int, channels []int, delay time.Duration) error {
+	return r.mirror("CG NEXT", func(c *client) error {
+		return c.NextCGData(template, layer, channels, delay)
+	})
+}
+
+func (r *redundantClient) UpdateCGData(template string, layer int, channels []int, data map[string]any) error {
+	return r.mirror("CG UPDATE", func(c *client) error {
+		return c.UpdateCGData(template, layer, channels, data)
+	})
+}
+
+func (r *redundantClient) PlayMedia(filename string, layer int, channels []int, loop bool, delay time.Duration) error {
+	return r.mirror("PLAY", func(c *client) error {
+		return c.PlayMedia(filename, layer, channels, loop, delay)
+	})
+}
+
+func (r *redundantClient) StopMedia(layer int, channels []int, delay time.Duration) error {
+	return r.mirror("STOP", func(c *client) error {
+		return c.StopMedia(layer, channels, delay)
+	})
+}
+
+func (r *redundantClient) PlayProducer(producer types.Producer, layer int, channels []int, delay time.Duration) error {
+	return r.mirror("PLAY", func(c *client) error {
+		return c.PlayProducer(producer, layer, channels, delay)
+	})
+}
+
+func (r *redundantClient) ClearChannels(channels []int) {
+	_ = r.mirror("CLEAR", func(c *client) error {
+		c.ClearChannels(channels)
+		return nil
+	})
+}
+
+func (r *redundantClient) ClearAll() {
+	_ = r.mirror("CLEAR", func(c *client) error {
+		c.ClearAll()
+		return nil
+	})
+}
+
+func (r *redundantClient) Close() {
+	r.cancel()
+	r.wg.Wait()
+	r.primary.Close()
+	r.backup.Close()
+}
@@ -0,0 +1,104 @@
+package casparcg
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	"github.com/overlayfox/caspaw-cg/src/events"
+	"github.com/overlayfox/caspaw-cg/src/types"
+)
+
+func TestRedundantClientCheck(t *testing.T) {
+	const (
+		primary = types.CasparCGServerRolePrimary
+		backup  = types.CasparCGServerRoleBackup
+	)
+	type tick struct {
+		primaryAlive bool
+		backupAlive  bool
+		failover     bool // the operator or a late failover switches to the backup before the tick
+		want         types.CasparCGServerRole
+	}
+
+	tests := []struct {
+		name  string
+		ticks []tick
+	}{
+		{
+			name: "failover and failback",
+			ticks: []tick{
+				{backupAlive: true, want: primary},
+				{backupAlive: true, want: primary},
+				{backupAlive: true, want: backup},
+				{primaryAlive: true, backupAlive: true, want: backup},
+				{primaryAlive: true, backupAlive: true, want: backup},
+				{primaryAlive: true, backupAlive: true, want: primary},
+			},
+		},
+		{
+			name: "primary recovers while a failover is in flight",
+			ticks: []tick{
+				{primaryAlive: true, backupAlive: true, want: primary},
+				{primaryAlive: true, backupAlive: true, want: primary},
+				{primaryAlive: true, backupAlive: true, want: primary},
+				{primaryAlive: true, backupAlive: true, want: primary},
+				{primaryAlive: true, backupAlive: true, failover: true, want: primary},
+			},
+		},
+		{
+			name: "counter restarts after failing back",
+			ticks: []tick{
+				{backupAlive: true, want: primary},
+				{backupAlive: true, want: primary},
+				{backupAlive: true, want: backup},
+				{primaryAlive: true, backupAlive: true, want: backup},
+				{primaryAlive: true, backupAlive: true, want: backup},
+				{primaryAlive: true, backupAlive: true, want: primary},
+				{primaryAlive: true, backupAlive: true, failover: true, want: backup},
+				{primaryAlive: true, backupAlive: true, want: backup},
+				{primaryAlive: true, backupAlive: true, want: primary},
+			},
+		},
+		{
+			name: "no failover without a live backup",
+			ticks: []tick{
+				{want: primary},
+				{want: primary},
+				{want: primary},
+				{want: primary},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			processor := events.NewProcessor(ctx, zerolog.Nop())
+			defer processor.Close()
+
+			cfg := &Config{
+				Host:              "127.0.0.1",
+				Port:              5250,
+				FailoverThreshold: 3,
+				AutoFailback:      true,
+				Backup:            &Config{Host: "127.0.0.1", Port: 5251},
+			}
+			r := NewRedundantClient(ctx, zerolog.Nop(), cfg, processor).(*redundantClient)
+
+			failed, recovered := 0, 0
+			for i, tick := range tt.ticks {
+				r.primary.alive.Store(tick.primaryAlive)
+				r.backup.alive.Store(tick.backupAlive)
+				if tick.failover {
+					r.setActive(backup)
+				}
+				r.check(&failed, &recovered)
+				if got := r.GetActive(); got != tick.want {
+					t.Fatalf("tick %d: active = %s, want %s", i, got, tick.want)
+				}
+			}
+		})
+	}
+}
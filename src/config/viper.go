@@ -17,6 +17,15 @@ type Config struct {
 	CasparCGClient    *casparcg.Config `mapstructure:"casparcg_client"`
 }
 
+// Redacted returns a copy of the config without secrets, see data.Config.Redacted
+func (c Config) Redacted() Config {
+	if c.DataSourceManager != nil {
+		dataSourceManager := c.DataSourceManager.Redacted()
+		c.DataSourceManager = &dataSourceManager
+	}
+	return c
+}
+
 type Defaulter interface {
 	Default()
 }
@@ -63,7 +72,10 @@ func applyDefaults(target any) {
 // applyValidation checks the main struct and its immediate fields for the Validator interface
 func applyValidation(target any) error {
 	v := reflect.ValueOf(target)
-	if v.Kind() == reflect.Pointer {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil // optional sections that are not configured have nothing to validate
+		}
 		v = v.Elem()
 	}
 
@@ -0,0 +1,23 @@
+package data
+
+import "testing"
+
+func TestFeedDataSourceLocalPathWindows(t *testing.T) {
+	tests := []struct {
+		url  string
+		path string
+	}{
+		{url: `C:\feeds\news.xml`, path: `C:\feeds\news.xml`},
+		{url: "file:///C:/feeds/news.xml", path: `C:\feeds\news.xml`},
+		{url: "file://server/share/news.xml", path: `\\server\share\news.xml`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			fds := FeedDataSource{URL: tt.url}
+			path, local, err := fds.LocalPath()
+			if err != nil || !local || path != tt.path {
+				t.Errorf("LocalPath() = %q, %v, %v, want %q, true, nil", path, local, err, tt.path)
+			}
+		})
+	}
+}
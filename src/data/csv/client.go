@@ -0,0 +1,160 @@
+package csv
+
+import (
+	"context"
+	encodingcsv "encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/rs/zerolog"
+
+	d "github.com/overlayfox/caspaw-cg/src/data"
+	"github.com/overlayfox/caspaw-cg/src/types"
+)
+
+// reloadDebounce groups the burst of write events editors and file shares produce for a single save
+const reloadDebounce = 200 * time.Millisecond
+
+type client struct {
+	logger zerolog.Logger
+
+	cfg            d.CSVDataSource
+	eventProcessor types.EventProcessor
+
+	path    string
+	records [][]string
+
+	fields *d.Fields
+	mtx    sync.RWMutex // guards records
+
+	watcher *d.FileWatcher
+}
+
+func NewClient(ctx context.Context, logger zerolog.Logger, cfg d.CSVDataSource, eventProcessor types.EventProcessor) (types.DataSource, error) {
+	absPath, err := filepath.Abs(cfg.FilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve absolute path for csv file: %w", err)
+	}
+
+	logger = logger.With().Str("component", fmt.Sprintf("csv-client-%s", cfg.Name)).Logger()
+	client := &client{
+		logger:         logger,
+		cfg:            cfg,
+		eventProcessor: eventProcessor,
+
+		path: absPath,
+
+		fields: d.NewFields(cfg.Name, logger, eventProcessor),
+	}
+
+	records, err := client.readFile()
+	if err != nil {
+		return nil, err
+	}
+	client.records = records
+
+	// a half written file is reloaded again on the next write event
+	client.watcher, err = d.WatchFiles(ctx, logger, []string{absPath}, reloadDebounce, 0, client.reload)
+	if err != nil {
+		return nil, err
+	}
+
+	return client, nil
+}
+
+func (c *client) GetName() string {
+	return c.cfg.Name
+}
+
+func (c *client) Subscribe(locations []types.Location) (string, error) {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+
+	return c.fields.Subscribe(locations, c.lookup)
+}
+
+func (c *client) Unsubscribe(handle string) error {
+	_, err := c.fields.Unsubscribe(handle)
+	return err
+}
+
+func (c *client) Get(location types.Location) (types.Data, error) {
+	return c.fields.Get(location)
+}
+
+func (c *client) Close() {
+	c.logger.Info().Msg("closing csv client")
+	c.watcher.Close()
+	c.logger.Info().Msg("csv client closed")
+}
+
+// lookup resolves a "Sheet!A1" or "Sheet!Name[ID=17]" key against the loaded records, cells outside the file are empty.
+// The caller must hold c.mtx.
+func (c *client) lookup(key string) (any, error) {
+	if types.IsRecordKey(key) {
+		record, err := types.ParseRecordKey(key)
+		if err != nil {
+			return nil, err
+		}
+		if record.Sheet != c.cfg.Sheet {
+			return nil, fmt.Errorf("unknown sheet '%s' in location '%s', this csv file is addressed as '%s'", record.Sheet, key, c.cfg.Sheet)
+		}
+		return types.FindRecord(record, c.records)
+	}
+
+	cell, err := types.ParseCell(key)
+	if err != nil {
+		return nil, err
+	}
+	if cell.Sheet != c.cfg.Sheet {
+		return nil, fmt.Errorf("unknown sheet '%s' in location '%s', this csv file is addressed as '%s'", cell.Sheet, key, c.cfg.Sheet)
+	}
+
+	if cell.Row > len(c.records) || cell.Column > len(c.records[cell.Row-1]) {
+		return nil, nil
+	}
+	return c.records[cell.Row-1][cell.Column-1], nil
+}
+
+func (c *client) readFile() ([][]string, error) {
+	f, err := os.Open(c.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open csv file: %w", err)
+	}
+	defer f.Close()
+
+	delimiter, _ := utf8.DecodeRuneInString(c.cfg.Delimiter)
+	reader := encodingcsv.NewReader(f)
+	reader.Comma = delimiter
+	reader.FieldsPerRecord = -1 // exports rarely pad every row to the same width
+	reader.LazyQuotes = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse csv file: %w", err)
+	}
+	return records, nil
+}
+
+// reload reads the changed file and emits events for the subscribed cells whose value changed
+func (c *client) reload() error {
+	records, err := c.readFile()
+	if err != nil {
+		return err
+	}
+
+	c.mtx.Lock()
+	c.records = records
+	changed, err := c.fields.Refresh(c.lookup)
+	c.mtx.Unlock()
+	if err != nil {
+		c.logger.Warn().Err(err).Msg("failed to read subscribed cells after reload")
+	}
+
+	c.fields.Emit(changed)
+	return nil
+}
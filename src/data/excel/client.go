@@ -0,0 +1,172 @@
+package excel
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/xuri/excelize/v2"
+
+	d "github.com/overlayfox/caspaw-cg/src/data"
+	"github.com/overlayfox/caspaw-cg/src/types"
+)
+
+const (
+	// reloadDebounce groups the burst of events Excel produces for a single save
+	reloadDebounce = 500 * time.Millisecond
+	// reloadRetries is how often a workbook that can't be read yet is retried before waiting for the next save
+	reloadRetries = 4
+)
+
+type client struct {
+	logger zerolog.Logger
+
+	cfg            d.XLSXDataSource
+	eventProcessor types.EventProcessor
+
+	path   string
+	sheets map[string][][]string // map[sheet]rows
+
+	fields *d.Fields
+	mtx    sync.RWMutex // guards sheets
+
+	watcher *d.FileWatcher
+}
+
+func NewClient(ctx context.Context, logger zerolog.Logger, cfg d.XLSXDataSource, eventProcessor types.EventProcessor) (types.DataSource, error) {
+	absPath, err := filepath.Abs(cfg.FilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve absolute path for workbook: %w", err)
+	}
+
+	logger = logger.With().Str("component", fmt.Sprintf("xlsx-client-%s", cfg.Name)).Logger()
+	client := &client{
+		logger:         logger,
+		cfg:            cfg,
+		eventProcessor: eventProcessor,
+
+		path: absPath,
+
+		fields: d.NewFields(cfg.Name, logger, eventProcessor),
+	}
+
+	sheets, err := client.readWorkbook()
+	if err != nil {
+		return nil, err
+	}
+	client.sheets = sheets
+
+	// reads fail while Excel is still writing the file, they are retried with a growing delay
+	client.watcher, err = d.WatchFiles(ctx, logger, []string{absPath}, reloadDebounce, reloadRetries, client.reload)
+	if err != nil {
+		return nil, err
+	}
+
+	return client, nil
+}
+
+func (c *client) GetName() string {
+	return c.cfg.Name
+}
+
+func (c *client) Subscribe(locations []types.Location) (string, error) {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+
+	return c.fields.Subscribe(locations, c.lookup)
+}
+
+func (c *client) Unsubscribe(handle string) error {
+	_, err := c.fields.Unsubscribe(handle)
+	return err
+}
+
+func (c *client) Get(location types.Location) (types.Data, error) {
+	return c.fields.Get(location)
+}
+
+func (c *client) Close() {
+	c.logger.Info().Msg("closing xlsx client")
+	c.watcher.Close()
+	c.logger.Info().Msg("xlsx client closed")
+}
+
+// lookup resolves a "Sheet!A1" or "Sheet!Name[ID=17]" key against the loaded workbook, cells outside the used range are empty.
+// The caller must hold c.mtx.
+func (c *client) lookup(key string) (any, error) {
+	if types.IsRecordKey(key) {
+		record, err := types.ParseRecordKey(key)
+		if err != nil {
+			return nil, err
+		}
+		rows, ok := c.sheets[record.Sheet]
+		if !ok {
+			return nil, fmt.Errorf("unknown sheet '%s' in location '%s'", record.Sheet, key)
+		}
+		return types.FindRecord(record, rows)
+	}
+
+	cell, err := types.ParseCell(key)
+	if err != nil {
+		return nil, err
+	}
+	rows, ok := c.sheets[cell.Sheet]
+	if !ok {
+		return nil, fmt.Errorf("unknown sheet '%s' in location '%s'", cell.Sheet, key)
+	}
+
+	if cell.Row > len(rows) || cell.Column > len(rows[cell.Row-1]) {
+		return nil, nil
+	}
+	return rows[cell.Row-1][cell.Column-1], nil
+}
+
+// readWorkbook reads every sheet of the workbook into memory.
+// The file is read in one go and closed right away so Excel is never blocked from saving it.
+func (c *client) readWorkbook() (map[string][][]string, error) {
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read workbook: %w", err)
+	}
+
+	opts := excelize.Options{RawCellValue: c.cfg.RawValues}
+	f, err := excelize.OpenReader(bytes.NewReader(data), opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open workbook: %w", err)
+	}
+	defer f.Close()
+
+	sheets := make(map[string][][]string)
+	for _, sheet := range f.GetSheetList() {
+		rows, err := f.GetRows(sheet, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read sheet '%s': %w", sheet, err)
+		}
+		sheets[sheet] = rows
+	}
+	return sheets, nil
+}
+
+// reload reads the saved workbook and emits events for the subscribed cells whose value changed
+func (c *client) reload() error {
+	sheets, err := c.readWorkbook()
+	if err != nil {
+		return err
+	}
+
+	c.mtx.Lock()
+	c.sheets = sheets
+	changed, err := c.fields.Refresh(c.lookup)
+	c.mtx.Unlock()
+	if err != nil {
+		c.logger.Warn().Err(err).Msg("failed to read subscribed cells after reload")
+	}
+
+	c.fields.Emit(changed)
+	return nil
+}
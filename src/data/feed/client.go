@@ -0,0 +1,255 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	d "github.com/overlayfox/caspaw-cg/src/data"
+	"github.com/overlayfox/caspaw-cg/src/types"
+)
+
+// sheetName is the sheet the feed items are exposed as
+const sheetName = "items"
+
+// client polls an RSS or Atom feed. Items are exposed as rows of a sheet named "items" with the columns
+// title, description, pubDate and link, addressed as "items.1.title" or A1-style as "'items'!A1" so
+// a ticker can rotate through a range such as "'items'!A1:A20".
+type client struct {
+	logger zerolog.Logger
+
+	cfg            d.FeedDataSource
+	eventProcessor types.EventProcessor
+
+	httpClient *http.Client
+	items      []item
+	fetched    bool
+
+	fields *d.Fields
+	mtx    sync.RWMutex // guards items
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewClient(ctx context.Context, logger zerolog.Logger, cfg d.FeedDataSource, eventProcessor types.EventProcessor) (types.DataSource, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	logger = logger.With().Str("component", fmt.Sprintf("feed-client-%s", cfg.Name)).Logger()
+	client := &client{
+		logger:         logger,
+		cfg:            cfg,
+		eventProcessor: eventProcessor,
+
+		httpClient: &http.Client{Timeout: cfg.Timeout},
+
+		fields: d.NewFields(cfg.Name, logger, eventProcessor),
+
+		ctx:    ctx,
+		cancel: cancel,
+	}
+	client.updateDataFields() // start update cycle
+
+	return client, nil
+}
+
+func (c *client) GetName() string {
+	return c.cfg.Name
+}
+
+func (c *client) Subscribe(locations []types.Location) (string, error) {
+	c.mtx.RLock()
+	fetched := c.fetched
+	c.mtx.RUnlock()
+	if !fetched {
+		if err := c.fetch(); err != nil {
+			return "", err
+		}
+	}
+
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+
+	return c.fields.Subscribe(locations, c.lookup)
+}
+
+func (c *client) Unsubscribe(handle string) error {
+	_, err := c.fields.Unsubscribe(handle)
+	return err
+}
+
+func (c *client) Get(location types.Location) (types.Data, error) {
+	return c.fields.Get(location)
+}
+
+func (c *client) Close() {
+	c.logger.Info().Msg("closing feed client")
+	c.cancel()
+	c.wg.Wait()
+	c.httpClient.CloseIdleConnections()
+	c.logger.Info().Msg("feed client closed")
+}
+
+// lookup resolves a location key against the current items, rows past the last item are empty.
+// The caller must hold c.mtx.
+func (c *client) lookup(key string) (any, error) {
+	var row, column int
+	if strings.Contains(key, "!") {
+		cell, err := types.ParseCell(key)
+		if err != nil {
+			return nil, err
+		}
+		if cell.Sheet != sheetName {
+			return nil, fmt.Errorf("unknown sheet '%s' in location '%s', feed items are in '%s'", cell.Sheet, key, sheetName)
+		}
+		if cell.Column > len(columns) {
+			return nil, fmt.Errorf("invalid column in location '%s', feeds have the columns %v", key, columns)
+		}
+		row, column = cell.Row, cell.Column
+	} else {
+		parts := strings.SplitN(key, ".", 3)
+		if len(parts) != 3 || parts[0] != sheetName {
+			return nil, fmt.Errorf("invalid location format '%s', use 'items.1.title' or 'items!A1'", key)
+		}
+		var err error
+		row, err = strconv.Atoi(parts[1])
+		if err != nil || row <= 0 {
+			return nil, fmt.Errorf("invalid row in location '%s', rows start at 1", key)
+		}
+		column = slices.Index(columns, parts[2]) + 1
+		if column == 0 {
+			return nil, fmt.Errorf("unknown column '%s' in location '%s', feeds have the columns %v", parts[2], key, columns)
+		}
+	}
+
+	if row > len(c.items) {
+		return nil, nil
+	}
+	return c.items[row-1].column(column), nil
+}
+
+// fetch reads and parses the feed and stores the filtered, deduplicated and capped items
+func (c *client) fetch() error {
+	content, err := c.read()
+	if err != nil {
+		return err
+	}
+	parsed, err := parse(content)
+	if err != nil {
+		return err
+	}
+
+	items := make([]item, 0, min(len(parsed), c.cfg.MaxItems))
+	seen := make(map[string]bool, len(parsed))
+	for _, i := range parsed {
+		if len(items) == c.cfg.MaxItems {
+			break
+		}
+		if !c.matches(i) {
+			continue
+		}
+		id := i.id
+		if id == "" {
+			id = i.link
+		}
+		if id == "" {
+			id = i.title
+		}
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		items = append(items, i)
+	}
+
+	c.mtx.Lock()
+	c.items = items
+	c.fetched = true
+	c.mtx.Unlock()
+	return nil
+}
+
+// matches applies the include and exclude keywords to the title and description of i
+func (c *client) matches(i item) bool {
+	text := strings.ToLower(i.title + " " + i.description)
+	contains := func(keyword string) bool {
+		return strings.Contains(text, strings.ToLower(keyword))
+	}
+
+	if slices.ContainsFunc(c.cfg.Exclude, contains) {
+		return false
+	}
+	return len(c.cfg.Include) == 0 || slices.ContainsFunc(c.cfg.Include, contains)
+}
+
+// read loads the feed from a http(s) url, a file:// url or a local path
+func (c *client) read() ([]byte, error) {
+	path, local, err := c.cfg.LocalPath()
+	if err != nil {
+		return nil, err
+	}
+	if local {
+		return os.ReadFile(path)
+	}
+
+	req, err := http.NewRequestWithContext(c.ctx, http.MethodGet, c.cfg.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/rss+xml, application/atom+xml, application/xml, text/xml")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %s", resp.Status)
+	}
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	return content, nil
+}
+
+func (c *client) updateDataFields() {
+	c.wg.Add(1)
+	ticker := time.NewTicker(c.cfg.PollInterval)
+
+	go func() {
+		defer c.wg.Done()
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-c.ctx.Done():
+				return
+			case <-ticker.C:
+				if c.fields.Len() == 0 {
+					continue
+				}
+
+				if err := c.fetch(); err != nil {
+					c.logger.Error().Err(err).Msg("failed to fetch feed")
+					continue
+				}
+
+				c.mtx.RLock()
+				changed, _ := c.fields.Refresh(c.lookup)
+				c.mtx.RUnlock()
+
+				c.fields.Emit(changed)
+			}
+		}
+	}()
+}
@@ -0,0 +1,131 @@
+package feed
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html/charset"
+)
+
+// item is a single feed entry, its fields are the columns of the "items" sheet in this order
+type item struct {
+	id          string
+	title       string
+	description string
+	pubDate     string
+	link        string
+}
+
+// columns names the item fields for "items.<row>.<column>" keys, their position is the A1 column
+var columns = []string{"title", "description", "pubDate", "link"}
+
+func (i item) column(n int) string {
+	switch n {
+	case 1:
+		return i.title
+	case 2:
+		return i.description
+	case 3:
+		return i.pubDate
+	case 4:
+		return i.link
+	}
+	return ""
+}
+
+type rssItem struct {
+	GUID        string `xml:"guid"`
+	Title       string `xml:"title"`
+	Description string `xml:"description"`
+	PubDate     string `xml:"pubDate"`
+	Date        string `xml:"http://purl.org/dc/elements/1.1/ date"` // RSS 1.0
+	Link        string `xml:"link"`
+}
+
+type atomEntry struct {
+	ID        string `xml:"id"`
+	Title     string `xml:"title"`
+	Summary   string `xml:"summary"`
+	Content   string `xml:"content"`
+	Published string `xml:"published"`
+	Updated   string `xml:"updated"`
+	Links     []struct {
+		Href string `xml:"href,attr"`
+		Rel  string `xml:"rel,attr"`
+	} `xml:"link"`
+}
+
+// document matches RSS 2.0 (<rss><channel><item>), RSS 1.0 (<rdf:RDF><item>) and Atom (<feed><entry>)
+type document struct {
+	Channel struct {
+		Items []rssItem `xml:"item"`
+	} `xml:"channel"`
+	Items   []rssItem   `xml:"item"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+// parse decodes an RSS or Atom document into its items in feed order
+func parse(content []byte) ([]item, error) {
+	var doc document
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	decoder.CharsetReader = charset.NewReaderLabel
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to decode feed: %w", err)
+	}
+
+	items := make([]item, 0, len(doc.Channel.Items)+len(doc.Items)+len(doc.Entries))
+	for _, i := range append(doc.Channel.Items, doc.Items...) {
+		pubDate := i.PubDate
+		if pubDate == "" {
+			pubDate = i.Date
+		}
+		items = append(items, item{
+			id:          i.GUID,
+			title:       plainText(i.Title),
+			description: plainText(i.Description),
+			pubDate:     strings.TrimSpace(pubDate),
+			link:        strings.TrimSpace(i.Link),
+		})
+	}
+	for _, e := range doc.Entries {
+		description := e.Summary
+		if description == "" {
+			description = e.Content
+		}
+		pubDate := e.Published
+		if pubDate == "" {
+			pubDate = e.Updated
+		}
+		var link string
+		for _, l := range e.Links {
+			if l.Rel == "" || l.Rel == "alternate" {
+				link = l.Href
+				break
+			}
+		}
+		items = append(items, item{
+			id:          e.ID,
+			title:       plainText(e.Title),
+			description: plainText(description),
+			pubDate:     strings.TrimSpace(pubDate),
+			link:        strings.TrimSpace(link),
+		})
+	}
+	return items, nil
+}
+
+var (
+	tagPattern        = regexp.MustCompile(`<[^>]*>`)
+	whitespacePattern = regexp.MustCompile(`\s+`)
+)
+
+// plainText strips markup from titles and descriptions, tickers can't render HTML
+func plainText(s string) string {
+	s = tagPattern.ReplaceAllString(s, " ")
+	s = html.UnescapeString(s)
+	return strings.TrimSpace(whitespacePattern.ReplaceAllString(s, " "))
+}
@@ -0,0 +1,202 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/rs/zerolog"
+
+	"github.com/overlayfox/caspaw-cg/src/types"
+)
+
+// Fields holds the locations subscribed to a datasource with their latest values, so datasources only
+// look values up in their own state. Datasources calling it while holding their own mutex have to
+// always lock theirs first.
+type Fields struct {
+	source         string
+	logger         zerolog.Logger
+	eventProcessor types.EventProcessor
+
+	fields        []*types.Data
+	subscriptions *Subscriptions
+	mtx           sync.RWMutex
+}
+
+func NewFields(source string, logger zerolog.Logger, eventProcessor types.EventProcessor) *Fields {
+	return &Fields{
+		source:         source,
+		logger:         logger,
+		eventProcessor: eventProcessor,
+
+		fields:        make([]*types.Data, 0),
+		subscriptions: NewSubscriptions(),
+	}
+}
+
+// Subscribe registers a subscription to locations with the values lookup returns for their keys and
+// returns its handle. Nothing is subscribed if lookup fails for one of them.
+func (f *Fields) Subscribe(locations []types.Location, lookup func(key string) (any, error)) (string, error) {
+	if len(locations) == 0 {
+		return "", errors.New("no locations provided")
+	}
+
+	data := make([]*types.Data, 0, len(locations))
+	for _, loc := range locations {
+		value, err := lookup(loc.Key)
+		if err != nil {
+			return "", err
+		}
+		data = append(data, &types.Data{Location: loc, Value: value})
+	}
+	return f.Add(data), nil
+}
+
+// Add registers a subscription to data fetched by the datasource itself and returns its handle
+func (f *Fields) Add(data []*types.Data) string {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	handle, added := f.subscriptions.Add(data)
+	f.fields = append(f.fields, added...)
+	return handle
+}
+
+// Unsubscribe drops a subscription and returns the keys no subscription holds any variant of anymore,
+// so datasources can release what they keep per key
+func (f *Fields) Unsubscribe(handle string) ([]string, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	variants, err := f.subscriptions.Remove(handle)
+	if err != nil {
+		return nil, err
+	}
+	f.fields = RemoveFields(f.fields, variants)
+
+	keys := make([]string, 0, len(variants))
+	for _, variant := range variants {
+		if !f.subscriptions.Holds(variant.Key) && !slices.Contains(keys, variant.Key) {
+			keys = append(keys, variant.Key)
+		}
+	}
+	return keys, nil
+}
+
+// Get returns the field of the location's variant
+func (f *Fields) Get(location types.Location) (types.Data, error) {
+	f.mtx.RLock()
+	defer f.mtx.RUnlock()
+
+	variant := location.Variant()
+	for _, data := range f.fields {
+		if data.Variant() == variant {
+			return copyData(data), nil
+		}
+	}
+	return types.Data{}, fmt.Errorf("no data found for key: '%s'", location.Key)
+}
+
+// Locations returns the subscribed locations
+func (f *Fields) Locations() []types.Location {
+	f.mtx.RLock()
+	defer f.mtx.RUnlock()
+
+	locations := make([]types.Location, 0, len(f.fields))
+	for _, data := range f.fields {
+		locations = append(locations, data.Location)
+	}
+	return locations
+}
+
+// Len returns the number of subscribed locations, polling datasources skip polls without any
+func (f *Fields) Len() int {
+	f.mtx.RLock()
+	defer f.mtx.RUnlock()
+
+	return len(f.fields)
+}
+
+// Update stores values, matched to the fields by variant, and returns the fields whose value or
+// formatting changed. Fields missing from values keep their value.
+func (f *Fields) Update(values []types.Data) []types.Data {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	var changed []types.Data
+	for _, data := range f.fields {
+		for _, updated := range values {
+			if data.Variant() != updated.Variant() {
+				continue
+			}
+			if fmt.Sprintf("%v", data.Value) != fmt.Sprintf("%v", updated.Value) || !sameRich(data.Rich, updated.Rich) {
+				data.Value = updated.Value
+				data.Rich = updated.Rich
+				changed = append(changed, copyData(data))
+			}
+			break
+		}
+	}
+	return changed
+}
+
+// Refresh updates every field with the value lookup returns for its key and returns the fields whose
+// value changed. Fields lookup fails for keep their value, the errors are returned joined.
+func (f *Fields) Refresh(lookup func(key string) (any, error)) ([]types.Data, error) {
+	return f.RefreshMatching(func(string) bool { return true }, lookup)
+}
+
+// RefreshMatching is Refresh for the fields whose key matches, e.g. the topics a message was published on
+func (f *Fields) RefreshMatching(match func(key string) bool, lookup func(key string) (any, error)) ([]types.Data, error) {
+	f.mtx.RLock()
+	values := make([]types.Data, 0, len(f.fields))
+	var errs []error
+	for _, data := range f.fields {
+		if !match(data.Key) {
+			continue
+		}
+		value, err := lookup(data.Key)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", data.Key, err))
+			continue
+		}
+		values = append(values, types.Data{Location: data.Location, Value: value})
+	}
+	f.mtx.RUnlock()
+
+	return f.Update(values), errors.Join(errs...)
+}
+
+// Emit pushes a DataSourceValueUpdate for every changed field
+func (f *Fields) Emit(changed []types.Data) {
+	for _, data := range changed {
+		ev := types.DataSourceValueUpdate{
+			Source:      f.source,
+			LocationKey: data.Key,
+			Value:       data.Value,
+		}
+		if err := f.eventProcessor.Push(ev); err != nil {
+			f.logger.Error().Err(err).Str("key", data.Key).Msg("failed to emit datasource update event")
+		}
+	}
+}
+
+func copyData(data *types.Data) types.Data {
+	result := types.Data{
+		Location: data.Location,
+		Value:    data.Value,
+	}
+	if data.Rich != nil {
+		rich := *data.Rich
+		result.Rich = &rich
+	}
+	return result
+}
+
+func sameRich(a, b *types.RichData) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
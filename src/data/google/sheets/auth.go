@@ -0,0 +1,264 @@
+package sheets
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/option"
+	gs "google.golang.org/api/sheets/v4"
+
+	d "github.com/overlayfox/caspaw-cg/src/data"
+)
+
+// loginTimeout bounds how long a sign-in waits for the operator to finish signing in in the browser
+const loginTimeout = 5 * time.Minute
+
+// authenticate returns the client option for the service account and API key auth modes and an
+// identifier of the credentials, sheets sharing credentials share their rate limiter.
+// OAuth sign-ins are handled by oauthTokenSource.
+func authenticate(ctx context.Context, cfg d.GoogleSheetDataSource) (option.ClientOption, string, error) {
+	switch cfg.AuthMode {
+	case d.GoogleAuthModeAPIKey:
+		return option.WithAPIKey(cfg.APIKey), "api-key:" + cfg.APIKey, nil
+	default:
+		absPath, err := filepath.Abs(cfg.CredentialsFilePath)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to resolve absolute path for service account credentials file: %w", err)
+		}
+		jsonKey, err := os.ReadFile(absPath)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read service account credentials file: %w", err)
+		}
+
+		jwtConfig, err := google.JWTConfigFromJSON(jsonKey, gs.SpreadsheetsScope)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to parse service account credentials: %w", err)
+		}
+		return option.WithTokenSource(jwtConfig.TokenSource(ctx)), absPath, nil
+	}
+}
+
+// oauthTokenSource signs the operator in with the installed-app flow. A cached token is used right away,
+// otherwise requests fail as not signed in until signIn received a token through the browser.
+// Refreshed tokens are written back to the token file.
+type oauthTokenSource struct {
+	logger zerolog.Logger
+	config *oauth2.Config
+	path   string
+
+	source    oauth2.TokenSource // nil until signed in
+	last      string             // access token written last
+	err       error              // why the last sign-in failed
+	signingIn bool
+	mtx       sync.Mutex
+}
+
+func newOAuthTokenSource(ctx context.Context, logger zerolog.Logger, cfg d.GoogleSheetDataSource) (*oauthTokenSource, error) {
+	clientJSON, err := os.ReadFile(cfg.OAuthClientFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read oauth client file: %w", err)
+	}
+	oauthConfig, err := google.ConfigFromJSON(clientJSON, gs.SpreadsheetsScope)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse oauth client file: %w", err)
+	}
+	tokenPath, err := filepath.Abs(cfg.TokenFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve absolute path for token file: %w", err)
+	}
+
+	s := &oauthTokenSource{
+		logger: logger,
+		config: oauthConfig,
+		path:   tokenPath,
+	}
+	if token, err := readToken(tokenPath); err == nil {
+		s.source = oauthConfig.TokenSource(context.WithoutCancel(ctx), token)
+		s.last = token.AccessToken
+	}
+	return s, nil
+}
+
+func (s *oauthTokenSource) Token() (*oauth2.Token, error) {
+	s.mtx.Lock()
+	source, loginErr := s.source, s.err
+	s.mtx.Unlock()
+	if source == nil {
+		if loginErr != nil {
+			return nil, fmt.Errorf("not signed in to google: %w", loginErr)
+		}
+		return nil, errors.New("not signed in to google, finish signing in in the browser")
+	}
+
+	token, err := source.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if token.AccessToken != s.last {
+		s.last = token.AccessToken
+		if err := writeToken(s.path, token); err != nil {
+			s.logger.Warn().Err(err).Msg("failed to cache refreshed google token")
+		}
+	}
+	return token, nil
+}
+
+func (s *oauthTokenSource) SignedIn() bool {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.source != nil
+}
+
+// signIn opens the browser on Google's consent page and waits for the operator to sign in. It returns
+// right away if the operator is signed in or a sign-in is in progress.
+func (s *oauthTokenSource) signIn(ctx context.Context) error {
+	s.mtx.Lock()
+	if s.source != nil || s.signingIn {
+		s.mtx.Unlock()
+		return nil
+	}
+	s.signingIn = true
+	s.mtx.Unlock()
+
+	s.logger.Info().Msg("no cached google token, opening the browser to sign in")
+	token, err := loopbackLogin(ctx, s.logger, s.config)
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.signingIn = false
+	if err != nil {
+		s.err = err
+		return err
+	}
+	if err := writeToken(s.path, token); err != nil {
+		s.logger.Warn().Err(err).Msg("failed to cache google token, the next start asks to sign in again")
+	}
+	s.source = s.config.TokenSource(context.WithoutCancel(ctx), token)
+	s.last = token.AccessToken
+	s.err = nil
+	return nil
+}
+
+// loopbackLogin runs the OAuth installed-app flow with PKCE on a random local port
+func loopbackLogin(ctx context.Context, logger zerolog.Logger, config *oauth2.Config) (*oauth2.Token, error) {
+	oauthConfig := *config // the redirect url differs per sign-in
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for oauth redirect: %w", err)
+	}
+	defer listener.Close()
+
+	oauthConfig.RedirectURL = fmt.Sprintf("http://%s/callback", listener.Addr())
+	state := rand.Text()
+	verifier := oauth2.GenerateVerifier()
+
+	type result struct {
+		code string
+		err  error
+	}
+	results := make(chan result, 1)
+	deliver := func(res result) {
+		select {
+		case results <- res:
+		default: // a result was already delivered, e.g. the page was reloaded
+		}
+	}
+	server := &http.Server{
+		ReadHeaderTimeout: 10 * time.Second,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/callback" {
+				http.NotFound(w, r)
+				return
+			}
+			query := r.URL.Query()
+			switch {
+			case query.Get("state") != state:
+				http.Error(w, "invalid state", http.StatusBadRequest)
+				return
+			case query.Get("error") != "":
+				fmt.Fprintln(w, "Sign-in failed, you can close this window.")
+				deliver(result{err: fmt.Errorf("google sign-in failed: %s", query.Get("error"))})
+			default:
+				fmt.Fprintln(w, "Signed in, you can close this window and return to CaspawCG.")
+				deliver(result{code: query.Get("code")})
+			}
+		}),
+	}
+	go server.Serve(listener)
+	defer server.Close()
+
+	authURL := oauthConfig.AuthCodeURL(state, oauth2.AccessTypeOffline, oauth2.S256ChallengeOption(verifier))
+	logger.Info().Str("url", authURL).Msg("sign in to google to grant access to your sheets")
+	if err := openBrowser(authURL); err != nil {
+		logger.Warn().Err(err).Msg("failed to open the browser, open the logged url manually")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, loginTimeout)
+	defer cancel()
+	select {
+	case <-ctx.Done():
+		return nil, errors.New("timed out waiting for google sign-in")
+	case res := <-results:
+		if res.err != nil {
+			return nil, res.err
+		}
+		token, err := oauthConfig.Exchange(ctx, res.code, oauth2.VerifierOption(verifier))
+		if err != nil {
+			return nil, fmt.Errorf("failed to exchange google authorization code: %w", err)
+		}
+		return token, nil
+	}
+}
+
+func readToken(path string) (*oauth2.Token, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var token oauth2.Token
+	if err := json.Unmarshal(content, &token); err != nil {
+		return nil, err
+	}
+	if token.RefreshToken == "" {
+		return nil, errors.New("cached token can't be refreshed")
+	}
+	return &token, nil
+}
+
+// writeToken stores the token readable by the current user only, it grants access to their sheets
+func writeToken(path string, token *oauth2.Token) error {
+	content, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, content, 0o600)
+}
+
+func openBrowser(url string) error {
+	switch runtime.GOOS {
+	case "windows":
+		return exec.Command("rundll32", "url.dll,FileProtocolHandler", url).Start()
+	case "darwin":
+		return exec.Command("open", url).Start()
+	default:
+		return exec.Command("xdg-open", url).Start()
+	}
+}
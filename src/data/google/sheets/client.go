@@ -4,8 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"os"
-	"path/filepath"
+	"maps"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -14,21 +14,31 @@ import (
 	"github.com/overlayfox/caspaw-cg/src/types"
 
 	"github.com/rs/zerolog"
-	"golang.org/x/oauth2/google"
+	"golang.org/x/time/rate"
 	"google.golang.org/api/option"
 	gs "google.golang.org/api/sheets/v4"
 )
 
+// maxBackoff caps how far polling slows down while Google reports the quota as exceeded
+const maxBackoff = 5 * time.Minute
+
 type client struct {
 	logger zerolog.Logger
 
 	cfg            d.GoogleSheetDataSource
 	eventProcessor types.EventProcessor
 
-	dataFields []*types.Data
-	mtx        sync.RWMutex
+	fields *d.Fields
 
 	service *gs.Service
+	login   *oauthTokenSource // nil unless the operator signs in with OAuth
+	limiter *rate.Limiter     // shared with every sheet using the same credentials
+
+	mtx          sync.RWMutex // guards the polling state below
+	pollInterval time.Duration
+	onAir        int           // number of SetOnAir(true) calls not yet matched by SetOnAir(false)
+	backoff      time.Duration // minimum interval after quota errors, zero if the last requests succeeded
+	reschedule   chan struct{} // wakes the poll loop up so interval changes apply right away
 
 	ctx    context.Context
 	cancel context.CancelFunc
@@ -41,39 +51,53 @@ type Dependencies struct {
 }
 
 func NewClient(ctx context.Context, logger zerolog.Logger, cfg d.GoogleSheetDataSource, eventProcessor types.EventProcessor) (types.DataSource, error) {
-	absPath, err := filepath.Abs(cfg.CredentialsFilePath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to resolve absolute path for service account credentials file: %w", err)
-	}
-	jsonKey, err := os.ReadFile(absPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read service account credentials file: %w", err)
-	}
-
-	jwtConfig, err := google.JWTConfigFromJSON(jsonKey, gs.SpreadsheetsScope)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse service account credentials: %w", err)
+	logger = logger.With().Str("component", fmt.Sprintf("google-sheets-client-%s", cfg.SpreadSheetID)).Logger()
+
+	// OAuth sign-ins wait for the operator, they run in the background once the client exists
+	var login *oauthTokenSource
+	var auth option.ClientOption
+	var credentialsID string
+	var err error
+	if cfg.AuthMode == d.GoogleAuthModeOAuth {
+		login, err = newOAuthTokenSource(ctx, logger, cfg)
+		if err != nil {
+			return nil, err
+		}
+		auth, credentialsID = option.WithTokenSource(login), login.path
+	} else {
+		auth, credentialsID, err = authenticate(ctx, cfg)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	service, err := gs.NewService(ctx, option.WithTokenSource(jwtConfig.TokenSource(ctx)))
+	service, err := gs.NewService(ctx, auth)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Google Sheets service: %w", err)
 	}
 
 	ctx, cancel := context.WithCancel(ctx)
 	client := &client{
-		logger:         logger.With().Str("component", fmt.Sprintf("google-sheets-client-%s", cfg.SpreadSheetID)).Logger(),
+		logger:         logger,
 		cfg:            cfg,
 		eventProcessor: eventProcessor,
 
-		dataFields: make([]*types.Data, 0),
+		fields: d.NewFields(cfg.Name, logger, eventProcessor),
 
 		service: service,
+		login:   login,
+		limiter: limiterFor(credentialsID, cfg.RequestsPerMinute),
+
+		pollInterval: cfg.PollInterval,
+		reschedule:   make(chan struct{}, 1),
 
 		ctx:    ctx,
 		cancel: cancel,
 	}
 	client.updateDataFields() // start update cycle
+	if login != nil && !login.SignedIn() {
+		client.SignIn()
+	}
 
 	return client, nil
 }
@@ -82,49 +106,73 @@ func (c *client) GetName() string {
 	return c.cfg.Name
 }
 
-func (c *client) Prime(locations []types.Location) error {
+// Subscribe fetches the locations right away, afterwards they are polled with every other subscribed location
+func (c *client) Subscribe(locations []types.Location) (string, error) {
 	result, err := c.batchFetch(locations)
 	if err != nil {
-		return err
+		return "", err
 	}
-	c.mtx.Lock()
-	defer c.mtx.Unlock()
-	c.dataFields = result
-	return nil
+	return c.fields.Add(result), nil
 }
 
-func (c *client) RemovePrime(keys []string) error {
-	c.mtx.Lock()
-	defer c.mtx.Unlock()
+func (c *client) Unsubscribe(handle string) error {
+	_, err := c.fields.Unsubscribe(handle)
+	return err
+}
 
-	for _, key := range keys {
-		for i, data := range c.dataFields {
-			if data.Key == key {
-				c.dataFields = append(c.dataFields[:i], c.dataFields[i+1:]...)
-				break
-			}
-		}
+func (c *client) Get(location types.Location) (types.Data, error) {
+	return c.fields.Get(location)
+}
+
+func (c *client) SignedIn() bool {
+	return c.login == nil || c.login.SignedIn()
+}
+
+// SignIn opens the browser for the operator to sign in to Google and returns without waiting for them.
+// Until they did, subscribing fails as not signed in and polls are skipped.
+func (c *client) SignIn() error {
+	if c.login == nil {
+		return fmt.Errorf("datasource '%s' does not sign in with oauth", c.cfg.Name)
 	}
+	c.wg.Go(func() {
+		if err := c.login.signIn(c.ctx); err != nil {
+			c.logger.Error().Err(err).Msg("google sign-in failed")
+			return
+		}
+	})
 	return nil
 }
 
-func (c *client) Get(key string) (types.Data, error) {
+func (c *client) GetPollInterval() time.Duration {
 	c.mtx.RLock()
 	defer c.mtx.RUnlock()
 
-	for _, data := range c.dataFields {
-		if data.Key == key {
-			return types.Data{
-				Location: types.Location{
-					Key:  data.Key,
-					Type: data.Type,
-				},
-				Value: data.Value,
-			}, nil
-		}
+	return c.pollInterval
+}
+
+func (c *client) SetPollInterval(interval time.Duration) error {
+	if interval < time.Second {
+		return errors.New("poll interval must be at least 1s")
+	}
+
+	c.mtx.Lock()
+	c.pollInterval = interval
+	c.mtx.Unlock()
+
+	c.wakeUp()
+	return nil
+}
+
+func (c *client) SetOnAir(onAir bool) {
+	c.mtx.Lock()
+	if onAir {
+		c.onAir++
+	} else if c.onAir > 0 {
+		c.onAir--
 	}
+	c.mtx.Unlock()
 
-	return types.Data{}, fmt.Errorf("no data found for key: '%s'", key)
+	c.wakeUp()
 }
 
 func (c *client) Close() {
@@ -134,109 +182,224 @@ func (c *client) Close() {
 	c.logger.Info().Msg("google sheets client closed")
 }
 
-// fetch fetches a singular datapoint from the google sheet
+// batchFetch fetches single cells, named ranges and record locations ("'Sheet1'!Name[ID=17]"), one request per value
+// render used plus one for the formatting of rich locations. Record locations are resolved against
+// their whole sheet so they follow rows that moved.
 func (c *client) batchFetch(emptyData []types.Location) ([]*types.Data, error) {
 	if len(emptyData) == 0 {
 		return nil, errors.New("no locations provided")
 	}
-	for _, loc := range emptyData {
-		if strings.Contains(loc.Key, ":") || !strings.Contains(loc.Key, "!") {
-			return nil, fmt.Errorf("invalid location format '%s', use 'sheet1!A1'", loc.Key)
+
+	// a BatchGet request reads all ranges with the same render option
+	renders := make(map[types.ValueRender][]int) // map[render]indexes in emptyData
+	for i, loc := range emptyData {
+		render := loc.Render
+		switch render {
+		case "":
+			render = types.ValueRenderFormatted
+		case types.ValueRenderFormatted, types.ValueRenderUnformatted, types.ValueRenderFormula:
+		default:
+			return nil, fmt.Errorf("invalid value render '%s' for '%s', use %s, %s or %s", render, loc.Key,
+				types.ValueRenderFormatted, types.ValueRenderUnformatted, types.ValueRenderFormula)
+		}
+		renders[render] = append(renders[render], i)
+	}
+
+	result := make([]*types.Data, len(emptyData))
+	richCells := make(map[int]types.Cell) // map[index in emptyData]cell the rich location reads
+	for render, indexes := range renders {
+		locations := make([]types.Location, 0, len(indexes))
+		for _, i := range indexes {
+			locations = append(locations, emptyData[i])
+		}
+		values, cells, err := c.fetchValues(locations, render)
+		if err != nil {
+			return nil, err
+		}
+		for j, i := range indexes {
+			result[i] = &types.Data{
+				Location: emptyData[i],
+				Value:    values[j],
+			}
+			if emptyData[i].Rich {
+				result[i].Rich = &types.RichData{}
+				if cells[j] != nil {
+					richCells[i] = *cells[j]
+				}
+			}
 		}
 	}
 
-	keys := make([]string, 0, len(emptyData))
-	for _, loc := range emptyData {
+	if len(richCells) > 0 {
+		formats, err := c.fetchRich(slices.Collect(maps.Values(richCells)))
+		if err != nil {
+			return nil, err
+		}
+		for i, cell := range richCells {
+			if rich, ok := formats[cell.Key()]; ok {
+				result[i].Rich = rich
+			}
+		}
+	}
+	return result, nil
+}
+
+// fetchValues reads the locations in one BatchGet request. It returns their values and the cells they
+// currently point at, the cell is nil for records without a matching row.
+func (c *client) fetchValues(locations []types.Location, render types.ValueRender) ([]any, []*types.Cell, error) {
+	keys := make([]string, 0, len(locations))
+	records := make(map[int]types.RecordKey) // map[index in locations]record
+	sheetIndex := make(map[string]int)       // map[sheet]index of its range in keys
+	for i, loc := range locations {
+		if types.IsRecordKey(loc.Key) {
+			record, err := types.ParseRecordKey(loc.Key)
+			if err != nil {
+				return nil, nil, err
+			}
+			records[i] = record
+			continue
+		}
+		if !isNamedRange(loc.Key) && (strings.Contains(loc.Key, ":") || !strings.Contains(loc.Key, "!")) {
+			return nil, nil, fmt.Errorf("invalid location format '%s', use 'sheet1!A1', 'sheet1!Name[ID=17]' or a named range", loc.Key)
+		}
 		keys = append(keys, loc.Key)
 	}
+	for _, record := range records {
+		if _, ok := sheetIndex[record.Sheet]; !ok {
+			sheetIndex[record.Sheet] = len(keys)
+			keys = append(keys, fmt.Sprintf("'%s'", record.Sheet))
+		}
+	}
+
+	if err := c.limiter.Wait(c.ctx); err != nil {
+		return nil, nil, err
+	}
 	resp, err := c.service.Spreadsheets.Values.
 		BatchGet(c.cfg.SpreadSheetID).
 		Ranges(keys...).
+		ValueRenderOption(string(render)).
 		Context(c.ctx).Do()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	if len(resp.ValueRanges) != len(emptyData) {
-		return nil, fmt.Errorf("unexpected number of value ranges in batchGet response: got %d, want %d", len(resp.ValueRanges), len(emptyData))
+	if len(resp.ValueRanges) != len(keys) {
+		return nil, nil, fmt.Errorf("unexpected number of value ranges in batchGet response: got %d, want %d", len(resp.ValueRanges), len(keys))
 	}
 
 	// Match by response order, not by string-comparing valueRange.Range against the
 	// requested key: Google echoes back a canonicalized range (e.g. dropping quotes
 	// that weren't strictly required), so exact string equality can silently fail to
 	// match. BatchGet guarantees ValueRanges are returned in the same order as Ranges.
-	result := make([]*types.Data, 0, len(resp.ValueRanges))
-	for i, valueRange := range resp.ValueRanges {
-		var fetchedData any
+	values := make([]any, len(locations))
+	cells := make([]*types.Cell, len(locations))
+	cell := 0
+	for i, loc := range locations {
+		if record, ok := records[i]; ok {
+			rows := resp.ValueRanges[sheetIndex[record.Sheet]].Values
+			values[i], err = types.FindRecord(record, rows)
+			if err != nil {
+				return nil, nil, err
+			}
+			if recordCell, found, _ := types.FindRecordCell(record, rows); found {
+				cells[i] = &recordCell
+			}
+			continue
+		}
+
+		valueRange := resp.ValueRanges[cell]
 		if len(valueRange.Values) > 0 && len(valueRange.Values[0]) > 0 {
-			fetchedData = valueRange.Values[0][0]
+			values[i] = valueRange.Values[0][0]
+		}
+		// named ranges read their top left cell, Google echoes the range they cover
+		ref := loc.Key
+		if isNamedRange(loc.Key) {
+			ref, _, _ = strings.Cut(valueRange.Range, ":")
+		}
+		if parsed, err := types.ParseCell(ref); err == nil {
+			cells[i] = &parsed
 		}
-		emptyDt := emptyData[i]
-		result = append(result, &types.Data{
-			Location: types.Location{
-				Key:  emptyDt.Key,
-				Type: emptyDt.Type,
-			},
-			Value: fetchedData,
-		})
+		cell++
 	}
+	return values, cells, nil
+}
 
-	return result, nil
+// interval returns how long to wait before the next poll: the on-air interval while the sheet feeds
+// graphics on air, stretched by the backoff while the quota is exceeded
+func (c *client) interval() time.Duration {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+
+	interval := c.pollInterval
+	if c.onAir > 0 {
+		interval = min(interval, c.cfg.OnAirPollInterval)
+	}
+	return max(interval, c.backoff)
+}
+
+// adjustBackoff doubles the backoff after a quota error and halves it again with every successful poll
+func (c *client) adjustBackoff(quotaExceeded bool) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if quotaExceeded {
+		c.backoff = min(max(c.backoff*2, c.pollInterval*2), maxBackoff)
+		c.logger.Warn().Dur("interval", c.backoff).Msg("google sheets quota exceeded, slowing down polling")
+		return
+	}
+	if c.backoff > 0 {
+		c.backoff /= 2
+		if c.backoff <= c.pollInterval {
+			c.backoff = 0
+		}
+	}
+}
+
+// wakeUp makes the poll loop pick up a changed interval without waiting for the current one to run out
+func (c *client) wakeUp() {
+	select {
+	case c.reschedule <- struct{}{}:
+	default:
+	}
 }
 
 func (c *client) updateDataFields() {
 	c.wg.Add(1)
-	ticker := time.NewTicker(10 * time.Second) // TODO: make this configurable via UI
 
 	go func() {
 		defer c.wg.Done()
-		defer ticker.Stop()
 
+		last := time.Now()
 		for {
+			timer := time.NewTimer(time.Until(last.Add(c.interval())))
 			select {
 			case <-c.ctx.Done():
+				timer.Stop()
 				return
-			case <-ticker.C:
-				c.mtx.RLock()
-				locations := make([]types.Location, 0, len(c.dataFields))
-				for _, data := range c.dataFields {
-					locations = append(locations, data.Location)
-				}
-				c.mtx.RUnlock()
-
-				if len(locations) == 0 {
+			case <-c.reschedule:
+				timer.Stop()
+			case <-timer.C:
+				last = time.Now()
+				locations := c.fields.Locations()
+				if len(locations) == 0 || !c.SignedIn() {
 					continue
 				}
 
 				result, err := c.batchFetch(locations)
 				if err != nil {
+					if isQuotaError(err) {
+						c.adjustBackoff(true)
+					}
 					c.logger.Error().Err(err).Msg("failed to fetch data fields")
 					continue
 				}
+				c.adjustBackoff(false)
 
-				c.mtx.Lock()
-				var changed []types.DataSourceValueUpdate
-				for _, data := range c.dataFields {
-					for _, updatedData := range result {
-						if data.Key == updatedData.Key {
-							if fmt.Sprintf("%v", data.Value) != fmt.Sprintf("%v", updatedData.Value) {
-								data.Value = updatedData.Value
-								changed = append(changed, types.DataSourceValueUpdate{
-									LocationKey: data.Key,
-									Value:       updatedData.Value,
-								})
-							}
-							break
-						}
-					}
-				}
-				c.mtx.Unlock()
-
-				for _, ev := range changed {
-					if err := c.eventProcessor.Push(ev); err != nil {
-						c.logger.Error().Err(err).Str("key", ev.LocationKey).Msg("failed to emit datasource update event")
-					}
+				values := make([]types.Data, 0, len(result))
+				for _, data := range result {
+					values = append(values, *data)
 				}
+				c.fields.Emit(c.fields.Update(values))
 			}
 		}
 	}()
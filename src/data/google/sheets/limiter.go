@@ -0,0 +1,55 @@
+package sheets
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+	"google.golang.org/api/googleapi"
+)
+
+// limiters holds one rate limiter per set of credentials, Google counts the quota per user and not per sheet
+var (
+	limiters   = make(map[string]*rate.Limiter)
+	limitersMu sync.Mutex
+)
+
+// limiterFor returns the limiter shared by every sheet using credentials.
+// If sheets configure different limits the lowest one wins.
+func limiterFor(credentials string, requestsPerMinute int) *rate.Limiter {
+	limitersMu.Lock()
+	defer limitersMu.Unlock()
+
+	limit := rate.Every(time.Minute / time.Duration(requestsPerMinute))
+	limiter, ok := limiters[credentials]
+	if !ok {
+		limiter = rate.NewLimiter(limit, 1)
+		limiters[credentials] = limiter
+	} else if limit < limiter.Limit() {
+		limiter.SetLimit(limit)
+	}
+	return limiter
+}
+
+// isQuotaError reports whether the API rejected a request for exceeding the rate limit or quota
+func isQuotaError(err error) bool {
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	if apiErr.Code == http.StatusTooManyRequests {
+		return true
+	}
+	if apiErr.Code != http.StatusForbidden {
+		return false
+	}
+	for _, e := range apiErr.Errors {
+		switch e.Reason {
+		case "rateLimitExceeded", "userRateLimitExceeded", "quotaExceeded":
+			return true
+		}
+	}
+	return false
+}
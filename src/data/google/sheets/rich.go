@@ -0,0 +1,95 @@
+package sheets
+
+import (
+	"fmt"
+	"math"
+
+	"google.golang.org/api/googleapi"
+	gs "google.golang.org/api/sheets/v4"
+
+	"github.com/overlayfox/caspaw-cg/src/types"
+)
+
+// richFields limits the grid data response to what types.RichData holds, full grid data is large
+const richFields googleapi.Field = "sheets(properties(title),data(startRow,startColumn,rowData(values(" +
+	"effectiveFormat(backgroundColor,textFormat(foregroundColor)),hyperlink,note,textFormatRuns(format(link(uri))))))"
+
+// fetchRich reads the colours, hyperlink and note of cells in one request, keyed by the cell key
+func (c *client) fetchRich(cells []types.Cell) (map[string]*types.RichData, error) {
+	ranges := make([]string, 0, len(cells))
+	seen := make(map[string]bool, len(cells))
+	for _, cell := range cells {
+		if key := cell.Key(); !seen[key] {
+			seen[key] = true
+			ranges = append(ranges, key)
+		}
+	}
+
+	if err := c.limiter.Wait(c.ctx); err != nil {
+		return nil, err
+	}
+	resp, err := c.service.Spreadsheets.
+		Get(c.cfg.SpreadSheetID).
+		Ranges(ranges...).
+		IncludeGridData(true).
+		Fields(richFields).
+		Context(c.ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch cell formatting: %w", err)
+	}
+
+	// sheets come back in spreadsheet order with one grid per requested range, match cells by position
+	result := make(map[string]*types.RichData, len(ranges))
+	for _, sheet := range resp.Sheets {
+		if sheet.Properties == nil {
+			continue
+		}
+		for _, grid := range sheet.Data {
+			for i, row := range grid.RowData {
+				for j, value := range row.Values {
+					cell := types.Cell{
+						Sheet:  sheet.Properties.Title,
+						Column: int(grid.StartColumn) + j + 1,
+						Row:    int(grid.StartRow) + i + 1,
+					}
+					result[cell.Key()] = richData(value)
+				}
+			}
+		}
+	}
+	return result, nil
+}
+
+func richData(value *gs.CellData) *types.RichData {
+	rich := &types.RichData{
+		Hyperlink: value.Hyperlink,
+		Note:      value.Note,
+	}
+	if format := value.EffectiveFormat; format != nil {
+		rich.BackgroundColor = hexColor(format.BackgroundColor)
+		if format.TextFormat != nil {
+			rich.TextColor = hexColor(format.TextFormat.ForegroundColor)
+		}
+	}
+	// cells with several links only report them on their text runs
+	for _, run := range value.TextFormatRuns {
+		if rich.Hyperlink != "" {
+			break
+		}
+		if run.Format != nil && run.Format.Link != nil {
+			rich.Hyperlink = run.Format.Link.Uri
+		}
+	}
+	return rich
+}
+
+// hexColor formats a colour as "#RRGGBB", the API omits channels that are zero
+func hexColor(color *gs.Color) string {
+	if color == nil {
+		return ""
+	}
+	channel := func(v float64) int {
+		return int(math.Round(min(max(v, 0), 1) * 255))
+	}
+	return fmt.Sprintf("#%02X%02X%02X", channel(color.Red), channel(color.Green), channel(color.Blue))
+}
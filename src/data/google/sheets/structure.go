@@ -0,0 +1,188 @@
+package sheets
+
+import (
+	"fmt"
+	"regexp"
+
+	"google.golang.org/api/googleapi"
+	gs "google.golang.org/api/sheets/v4"
+
+	"github.com/overlayfox/caspaw-cg/src/types"
+)
+
+// namedRangePattern matches the names Google allows for named ranges, e.g. "HomeScore"
+var namedRangePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// cellNamePattern matches names Google rejects for named ranges because they read as a cell, e.g. "AB12"
+var cellNamePattern = regexp.MustCompile(`^[A-Za-z]{1,3}[0-9]+$`)
+
+// structureFields limits the spreadsheet response to the tabs and named ranges
+const structureFields googleapi.Field = "sheets(properties(sheetId,title,gridProperties(rowCount,columnCount))),namedRanges(name,range)"
+
+// isNamedRange reports whether a location key is the name of a named range rather than a cell reference
+func isNamedRange(key string) bool {
+	return namedRangePattern.MatchString(key) && !cellNamePattern.MatchString(key)
+}
+
+func (c *client) ListSheets() ([]types.SheetInfo, error) {
+	spreadsheet, err := c.fetchStructure()
+	if err != nil {
+		return nil, err
+	}
+
+	sheets := make([]types.SheetInfo, 0, len(spreadsheet.Sheets))
+	for _, sheet := range spreadsheet.Sheets {
+		sheets = append(sheets, sheetInfo(sheet))
+	}
+	return sheets, nil
+}
+
+func (c *client) ListHeaders(sheet string) ([]string, error) {
+	if err := c.limiter.Wait(c.ctx); err != nil {
+		return nil, err
+	}
+	resp, err := c.service.Spreadsheets.Values.
+		Get(c.cfg.SpreadSheetID, fmt.Sprintf("'%s'!1:1", sheet)).
+		Context(c.ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch header row of sheet '%s': %w", sheet, err)
+	}
+
+	var headers []string
+	if len(resp.Values) > 0 {
+		headers = make([]string, 0, len(resp.Values[0]))
+		for _, value := range resp.Values[0] {
+			headers = append(headers, fmt.Sprintf("%v", value))
+		}
+	}
+	return headers, nil
+}
+
+func (c *client) ListNamedRanges() ([]types.NamedRange, error) {
+	spreadsheet, err := c.fetchStructure()
+	if err != nil {
+		return nil, err
+	}
+
+	sheetsByID := make(map[int64]types.SheetInfo, len(spreadsheet.Sheets))
+	for _, sheet := range spreadsheet.Sheets {
+		if sheet.Properties != nil {
+			sheetsByID[sheet.Properties.SheetId] = sheetInfo(sheet)
+		}
+	}
+
+	namedRanges := make([]types.NamedRange, 0, len(spreadsheet.NamedRanges))
+	for _, named := range spreadsheet.NamedRanges {
+		if named.Range == nil {
+			continue
+		}
+		sheet, ok := sheetsByID[named.Range.SheetId]
+		if !ok {
+			continue
+		}
+		namedRanges = append(namedRanges, types.NamedRange{
+			Name:  named.Name,
+			Range: a1Range(sheet, named.Range),
+		})
+	}
+	return namedRanges, nil
+}
+
+// ValidateKey checks cells against the size of their sheet, named ranges against the spreadsheet's
+// names and the columns of record locations against the header row. A record whose row doesn't
+// exist (yet) is valid, it reads as empty.
+func (c *client) ValidateKey(key string) error {
+	spreadsheet, err := c.fetchStructure()
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case isNamedRange(key):
+		for _, named := range spreadsheet.NamedRanges {
+			if named.Name == key {
+				return nil
+			}
+		}
+		return fmt.Errorf("no named range '%s' in the spreadsheet", key)
+	case types.IsRecordKey(key):
+		record, err := types.ParseRecordKey(key)
+		if err != nil {
+			return err
+		}
+		if _, err := findSheet(spreadsheet, record.Sheet); err != nil {
+			return err
+		}
+		headers, err := c.ListHeaders(record.Sheet)
+		if err != nil {
+			return err
+		}
+		_, _, err = types.FindRecordCell(record, [][]string{headers})
+		return err
+	default:
+		cell, err := types.ParseCell(key)
+		if err != nil {
+			return err
+		}
+		sheet, err := findSheet(spreadsheet, cell.Sheet)
+		if err != nil {
+			return err
+		}
+		if cell.Row > sheet.Rows || cell.Column > sheet.Columns {
+			return fmt.Errorf("%s is outside of sheet '%s' with %d rows and %d columns", key, sheet.Title, sheet.Rows, sheet.Columns)
+		}
+		return nil
+	}
+}
+
+func (c *client) fetchStructure() (*gs.Spreadsheet, error) {
+	if err := c.limiter.Wait(c.ctx); err != nil {
+		return nil, err
+	}
+	spreadsheet, err := c.service.Spreadsheets.
+		Get(c.cfg.SpreadSheetID).
+		Fields(structureFields).
+		Context(c.ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch spreadsheet structure: %w", err)
+	}
+	return spreadsheet, nil
+}
+
+func findSheet(spreadsheet *gs.Spreadsheet, title string) (types.SheetInfo, error) {
+	for _, sheet := range spreadsheet.Sheets {
+		if sheet.Properties != nil && sheet.Properties.Title == title {
+			return sheetInfo(sheet), nil
+		}
+	}
+	return types.SheetInfo{}, fmt.Errorf("no sheet named '%s' in the spreadsheet", title)
+}
+
+func sheetInfo(sheet *gs.Sheet) types.SheetInfo {
+	var info types.SheetInfo
+	if sheet.Properties == nil {
+		return info
+	}
+	info.Title = sheet.Properties.Title
+	if grid := sheet.Properties.GridProperties; grid != nil {
+		info.Rows = int(grid.RowCount)
+		info.Columns = int(grid.ColumnCount)
+	}
+	return info
+}
+
+// a1Range converts a grid range into A1 notation. Indexes are 0-based with exclusive ends,
+// the API omits zero indexes and the ends of ranges spanning whole rows or columns.
+func a1Range(sheet types.SheetInfo, grid *gs.GridRange) string {
+	endRow, endColumn := int(grid.EndRowIndex), int(grid.EndColumnIndex)
+	if endRow == 0 {
+		endRow = sheet.Rows
+	}
+	if endColumn == 0 {
+		endColumn = sheet.Columns
+	}
+
+	start := types.Cell{Sheet: sheet.Title, Column: int(grid.StartColumnIndex) + 1, Row: int(grid.StartRowIndex) + 1}
+	end := types.Cell{Sheet: sheet.Title, Column: endColumn, Row: endRow}
+	return types.Range{Locations: []types.Location{{Key: start.Key()}, {Key: end.Key()}}}.Key()
+}
@@ -0,0 +1,147 @@
+package sheets
+
+import (
+	"fmt"
+	"strings"
+
+	gs "google.golang.org/api/sheets/v4"
+
+	d "github.com/overlayfox/caspaw-cg/src/data"
+	"github.com/overlayfox/caspaw-cg/src/types"
+)
+
+// Set writes value to a single cell ("'Sheet1'!B2"), named range or record location ("'Sheet1'!Score[Team=Home]").
+// Values are entered as if typed into the sheet, so numbers, dates and formulas are parsed by Google.
+// Primed keys reading the cell are updated with the value the sheet stored, without waiting for the next poll.
+func (c *client) Set(key string, value any) error {
+	target, err := c.writeTarget(key)
+	if err != nil {
+		return err
+	}
+	return c.write(key, target, value)
+}
+
+// Modify reads the cell's unformatted value straight from the sheet, so a value changed in the sheet since
+// the last poll is not overwritten, and writes the value fn returns for it
+func (c *client) Modify(key string, fn func(current any) (any, error)) (any, error) {
+	target, err := c.writeTarget(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.limiter.Wait(c.ctx); err != nil {
+		return nil, err
+	}
+	resp, err := c.service.Spreadsheets.Values.
+		Get(c.cfg.SpreadSheetID, target).
+		ValueRenderOption(string(types.ValueRenderUnformatted)).
+		Context(c.ctx).Do()
+	if err != nil {
+		if isQuotaError(err) {
+			c.adjustBackoff(true)
+		}
+		return nil, fmt.Errorf("failed to read '%s': %w", key, err)
+	}
+	var current any
+	if len(resp.Values) > 0 && len(resp.Values[0]) > 0 {
+		current = resp.Values[0][0]
+	}
+
+	value, err := fn(current)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.write(key, target, value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// writeTarget returns the range a key is written through, named ranges are written through their name
+// and the value goes to their top left cell
+func (c *client) writeTarget(key string) (string, error) {
+	if c.cfg.AuthMode == d.GoogleAuthModeAPIKey {
+		return "", fmt.Errorf("datasource '%s' is read-only, API keys can't write to Google Sheets", c.cfg.Name)
+	}
+	if isNamedRange(key) {
+		return key, nil
+	}
+	cell, err := c.resolveCell(key)
+	if err != nil {
+		return "", err
+	}
+	return cell.Key(), nil
+}
+
+// write writes value to target and updates the primed keys reading it
+func (c *client) write(key, target string, value any) error {
+	if err := c.limiter.Wait(c.ctx); err != nil {
+		return err
+	}
+	resp, err := c.service.Spreadsheets.Values.
+		Update(c.cfg.SpreadSheetID, target, &gs.ValueRange{Values: [][]any{{value}}}).
+		ValueInputOption("USER_ENTERED").
+		IncludeValuesInResponse(true).
+		Context(c.ctx).Do()
+	if err != nil {
+		if isQuotaError(err) {
+			c.adjustBackoff(true)
+		}
+		return fmt.Errorf("failed to write '%s': %w", key, err)
+	}
+
+	var stored any
+	if resp.UpdatedData != nil && len(resp.UpdatedData.Values) > 0 && len(resp.UpdatedData.Values[0]) > 0 {
+		stored = resp.UpdatedData.Values[0][0]
+	}
+
+	var values []types.Data
+	for _, loc := range c.fields.Locations() {
+		if loc.Key != key && loc.Key != target {
+			continue
+		}
+		if loc.Render != "" && loc.Render != types.ValueRenderFormatted {
+			continue // the response holds the formatted value, the next poll reads the others
+		}
+		data, err := c.fields.Get(loc)
+		if err != nil {
+			continue
+		}
+		data.Value = stored // the formatting is only read by the next poll
+		values = append(values, data)
+	}
+	c.fields.Emit(c.fields.Update(values))
+	return nil
+}
+
+// resolveCell returns the cell a key writes to, record locations are looked up in the current sheet
+func (c *client) resolveCell(key string) (types.Cell, error) {
+	if !types.IsRecordKey(key) {
+		if strings.Contains(key, ":") {
+			return types.Cell{}, fmt.Errorf("invalid location format '%s', only single cells can be written", key)
+		}
+		return types.ParseCell(key)
+	}
+
+	record, err := types.ParseRecordKey(key)
+	if err != nil {
+		return types.Cell{}, err
+	}
+	if err := c.limiter.Wait(c.ctx); err != nil {
+		return types.Cell{}, err
+	}
+	resp, err := c.service.Spreadsheets.Values.
+		Get(c.cfg.SpreadSheetID, fmt.Sprintf("'%s'", record.Sheet)).
+		Context(c.ctx).Do()
+	if err != nil {
+		return types.Cell{}, fmt.Errorf("failed to look up '%s': %w", key, err)
+	}
+	cell, found, err := types.FindRecordCell(record, resp.Values)
+	if err != nil {
+		return types.Cell{}, err
+	}
+	if !found {
+		return types.Cell{}, fmt.Errorf("no row with %s '%s' in sheet '%s'", record.KeyColumn, record.KeyValue, record.Sheet)
+	}
+	return cell, nil
+}
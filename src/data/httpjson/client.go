@@ -0,0 +1,222 @@
+package httpjson
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/jmespath/go-jmespath"
+	"github.com/rs/zerolog"
+
+	d "github.com/overlayfox/caspaw-cg/src/data"
+	"github.com/overlayfox/caspaw-cg/src/types"
+)
+
+// client polls a JSON document over HTTP, location keys are JMESPath expressions such as "scores.home" or "results[0].name"
+type client struct {
+	logger zerolog.Logger
+
+	cfg            d.HTTPDataSource
+	eventProcessor types.EventProcessor
+
+	httpClient *http.Client
+
+	// document is the last successfully fetched response body, decoded
+	document     any
+	etag         string
+	lastModified string
+
+	fields      *d.Fields
+	expressions map[string]*jmespath.JMESPath // map[key]compiled expression
+	mtx         sync.RWMutex
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewClient(ctx context.Context, logger zerolog.Logger, cfg d.HTTPDataSource, eventProcessor types.EventProcessor) (types.DataSource, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	logger = logger.With().Str("component", fmt.Sprintf("http-client-%s", cfg.Name)).Logger()
+	client := &client{
+		logger:         logger,
+		cfg:            cfg,
+		eventProcessor: eventProcessor,
+
+		httpClient: &http.Client{Timeout: cfg.Timeout},
+
+		fields:      d.NewFields(cfg.Name, logger, eventProcessor),
+		expressions: make(map[string]*jmespath.JMESPath),
+
+		ctx:    ctx,
+		cancel: cancel,
+	}
+	client.updateDataFields() // start update cycle
+
+	return client, nil
+}
+
+func (c *client) GetName() string {
+	return c.cfg.Name
+}
+
+func (c *client) Subscribe(locations []types.Location) (string, error) {
+	c.mtx.RLock()
+	hasDocument := c.document != nil
+	c.mtx.RUnlock()
+	if !hasDocument {
+		if _, err := c.fetch(); err != nil {
+			return "", err
+		}
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	return c.fields.Subscribe(locations, c.evaluate)
+}
+
+func (c *client) Unsubscribe(handle string) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	keys, err := c.fields.Unsubscribe(handle)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		delete(c.expressions, key)
+	}
+	return nil
+}
+
+func (c *client) Get(location types.Location) (types.Data, error) {
+	return c.fields.Get(location)
+}
+
+func (c *client) Close() {
+	c.logger.Info().Msg("closing http client")
+	c.cancel()
+	c.wg.Wait()
+	c.httpClient.CloseIdleConnections()
+	c.logger.Info().Msg("http client closed")
+}
+
+// fetch requests the document and stores it, sending the last ETag and Last-Modified so
+// unchanged documents cost a 304 instead of a full body.
+// It returns false if the server reported the document as not modified.
+func (c *client) fetch() (bool, error) {
+	req, err := http.NewRequestWithContext(c.ctx, http.MethodGet, c.cfg.URL, nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Accept", "application/json")
+	for name, value := range c.cfg.Headers {
+		req.Header.Set(name, value)
+	}
+	switch c.cfg.Auth.Type {
+	case d.HTTPAuthTypeBasic:
+		req.SetBasicAuth(c.cfg.Auth.Username, c.cfg.Auth.Password)
+	case d.HTTPAuthTypeBearer:
+		req.Header.Set("Authorization", "Bearer "+c.cfg.Auth.Token)
+	}
+
+	c.mtx.RLock()
+	if c.etag != "" {
+		req.Header.Set("If-None-Match", c.etag)
+	}
+	if c.lastModified != "" {
+		req.Header.Set("If-Modified-Since", c.lastModified)
+	}
+	c.mtx.RUnlock()
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("unexpected status code: %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return false, fmt.Errorf("failed to read response body: %w", err)
+	}
+	var document any
+	if err := json.Unmarshal(body, &document); err != nil {
+		return false, fmt.Errorf("failed to decode response body: %w", err)
+	}
+
+	c.mtx.Lock()
+	c.document = document
+	c.etag = resp.Header.Get("ETag")
+	c.lastModified = resp.Header.Get("Last-Modified")
+	c.mtx.Unlock()
+	return true, nil
+}
+
+func (c *client) updateDataFields() {
+	c.wg.Add(1)
+	ticker := time.NewTicker(c.cfg.PollInterval)
+
+	go func() {
+		defer c.wg.Done()
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-c.ctx.Done():
+				return
+			case <-ticker.C:
+				if c.fields.Len() == 0 {
+					continue
+				}
+
+				modified, err := c.fetch()
+				if err != nil {
+					c.logger.Error().Err(err).Msg("failed to fetch data fields")
+					continue
+				}
+				if !modified {
+					continue
+				}
+
+				c.mtx.Lock()
+				changed, err := c.fields.Refresh(c.evaluate)
+				c.mtx.Unlock()
+				if err != nil {
+					c.logger.Error().Err(err).Msg("failed to evaluate expressions")
+				}
+
+				c.fields.Emit(changed)
+			}
+		}
+	}()
+}
+
+// evaluate applies the JMESPath expression key to the document, compiling it on first use.
+// The caller must hold c.mtx for writing.
+func (c *client) evaluate(key string) (any, error) {
+	expr, ok := c.expressions[key]
+	if !ok {
+		var err error
+		if expr, err = jmespath.Compile(key); err != nil {
+			return nil, fmt.Errorf("invalid JMESPath expression '%s': %w", key, err)
+		}
+		c.expressions[key] = expr
+	}
+	value, err := expr.Search(c.document)
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate '%s': %w", key, err)
+	}
+	return value, nil
+}
@@ -0,0 +1,235 @@
+package mqtt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	pahomqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/jmespath/go-jmespath"
+	"github.com/rs/zerolog"
+
+	d "github.com/overlayfox/caspaw-cg/src/data"
+	"github.com/overlayfox/caspaw-cg/src/types"
+)
+
+// connectTimeout bounds how long startup waits for the broker, the client keeps retrying in the background
+const connectTimeout = 5 * time.Second
+
+// message is the last payload received on a topic, seq orders messages across topics
+type message struct {
+	payload []byte
+	seq     uint64
+}
+
+// client subscribes to an MQTT broker. Location keys are topic filters, wildcards resolve to the
+// latest message on any matching topic, and may be followed by "::" and a JMESPath expression
+// evaluated against JSON payloads, e.g. "arena/+/clock::seconds".
+type client struct {
+	logger zerolog.Logger
+
+	cfg            d.MQTTDataSource
+	eventProcessor types.EventProcessor
+
+	mqtt     pahomqtt.Client
+	messages map[string]message // map[topic]last message
+	seq      uint64
+
+	fields      *d.Fields
+	expressions map[string]*jmespath.JMESPath // map[key]compiled expression, nil for raw payloads
+	mtx         sync.RWMutex
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func NewClient(ctx context.Context, logger zerolog.Logger, cfg d.MQTTDataSource, eventProcessor types.EventProcessor) (types.DataSource, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	logger = logger.With().Str("component", fmt.Sprintf("mqtt-client-%s", cfg.Name)).Logger()
+	client := &client{
+		logger:         logger,
+		cfg:            cfg,
+		eventProcessor: eventProcessor,
+
+		messages: make(map[string]message),
+
+		fields:      d.NewFields(cfg.Name, logger, eventProcessor),
+		expressions: make(map[string]*jmespath.JMESPath),
+
+		ctx:    ctx,
+		cancel: cancel,
+	}
+
+	opts := pahomqtt.NewClientOptions().
+		AddBroker(cfg.Broker).
+		SetClientID(cfg.ClientID).
+		SetUsername(cfg.Username).
+		SetPassword(cfg.Password).
+		SetAutoReconnect(true).
+		SetConnectRetry(true).
+		SetOnConnectHandler(client.subscribe). // subscriptions are lost on reconnect with a clean session
+		SetConnectionLostHandler(func(_ pahomqtt.Client, err error) {
+			client.logger.Warn().Err(err).Msg("connection to broker lost, reconnecting")
+		})
+	client.mqtt = pahomqtt.NewClient(opts)
+
+	token := client.mqtt.Connect()
+	if !token.WaitTimeout(connectTimeout) {
+		client.logger.Warn().Str("broker", cfg.Broker).Msg("broker not reachable yet, retrying in the background")
+	} else if err := token.Error(); err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to connect to broker: %w", err)
+	}
+
+	return client, nil
+}
+
+func (c *client) GetName() string {
+	return c.cfg.Name
+}
+
+// Subscribe accepts topics nothing was published on yet, their values are pushed once something is
+func (c *client) Subscribe(locations []types.Location) (string, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	return c.fields.Subscribe(locations, c.lookup)
+}
+
+func (c *client) Unsubscribe(handle string) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	keys, err := c.fields.Unsubscribe(handle)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		delete(c.expressions, key)
+	}
+	return nil
+}
+
+func (c *client) Get(location types.Location) (types.Data, error) {
+	return c.fields.Get(location)
+}
+
+func (c *client) Close() {
+	c.logger.Info().Msg("closing mqtt client")
+	c.cancel()
+	c.mqtt.Disconnect(250)
+	c.logger.Info().Msg("mqtt client closed")
+}
+
+// subscribe subscribes to the configured topics, the broker answers with the retained message of each matching topic
+func (c *client) subscribe(mqtt pahomqtt.Client) {
+	filters := make(map[string]byte, len(c.cfg.Topics))
+	for _, topic := range c.cfg.Topics {
+		filters[topic] = c.cfg.QoS
+	}
+
+	token := mqtt.SubscribeMultiple(filters, c.onMessage)
+	go func() {
+		if token.Wait(); token.Error() != nil {
+			c.logger.Error().Err(token.Error()).Strs("topics", c.cfg.Topics).Msg("failed to subscribe")
+			return
+		}
+		c.logger.Info().Strs("topics", c.cfg.Topics).Msg("subscribed to broker")
+	}()
+}
+
+// onMessage stores the payload and immediately emits events for the subscribed keys whose value changed
+func (c *client) onMessage(_ pahomqtt.Client, msg pahomqtt.Message) {
+	if c.ctx.Err() != nil {
+		return
+	}
+
+	c.mtx.Lock()
+	c.seq++
+	c.messages[msg.Topic()] = message{payload: msg.Payload(), seq: c.seq}
+	changed, err := c.fields.RefreshMatching(func(key string) bool {
+		filter, _ := splitKey(key)
+		return matchTopic(filter, msg.Topic())
+	}, c.lookup)
+	c.mtx.Unlock()
+	if err != nil {
+		c.logger.Error().Err(err).Str("topic", msg.Topic()).Msg("failed to evaluate payload")
+	}
+
+	c.fields.Emit(changed)
+}
+
+// lookup evaluates the latest message on any topic matching the key's filter, compiling its
+// expression on first use. Keys are empty until a matching message arrived.
+// The caller must hold c.mtx for writing.
+func (c *client) lookup(key string) (any, error) {
+	filter, expression := splitKey(key)
+	expr, ok := c.expressions[key]
+	if !ok && expression != "" {
+		var err error
+		if expr, err = jmespath.Compile(expression); err != nil {
+			return nil, fmt.Errorf("invalid JMESPath expression '%s': %w", expression, err)
+		}
+	}
+	c.expressions[key] = expr
+
+	var latest *message
+	for topic, msg := range c.messages {
+		if matchTopic(filter, topic) && (latest == nil || msg.seq > latest.seq) {
+			latest = &msg
+		}
+	}
+	if latest == nil {
+		return nil, nil
+	}
+	value, err := evaluate(expr, latest.payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate '%s': %w", key, err)
+	}
+	return value, nil
+}
+
+// splitKey separates the topic filter of a location key from its optional JMESPath expression
+func splitKey(key string) (filter string, expression string) {
+	filter, expression, _ = strings.Cut(key, "::")
+	return filter, expression
+}
+
+// evaluate returns the payload as a string, or the result of expr applied to the payload decoded as JSON
+func evaluate(expr *jmespath.JMESPath, payload []byte) (any, error) {
+	if expr == nil {
+		return string(payload), nil
+	}
+
+	var document any
+	if err := json.Unmarshal(payload, &document); err != nil {
+		return nil, fmt.Errorf("payload is not JSON: %w", err)
+	}
+	return expr.Search(document)
+}
+
+// matchTopic reports whether topic matches filter, where "+" matches a single level and a trailing "#"
+// matches any number of levels including the parent. Wildcards at the first level don't match "$" topics.
+func matchTopic(filter, topic string) bool {
+	filterLevels := strings.Split(filter, "/")
+	topicLevels := strings.Split(topic, "/")
+
+	if strings.HasPrefix(topic, "$") && (filterLevels[0] == "+" || filterLevels[0] == "#") {
+		return false
+	}
+	for i, level := range filterLevels {
+		if level == "#" {
+			return true
+		}
+		if i >= len(topicLevels) {
+			return false
+		}
+		if level != "+" && level != topicLevels[i] {
+			return false
+		}
+	}
+	return len(filterLevels) == len(topicLevels)
+}
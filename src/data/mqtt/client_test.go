@@ -0,0 +1,76 @@
+package mqtt
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jmespath/go-jmespath"
+)
+
+func TestMatchTopic(t *testing.T) {
+	tests := []struct {
+		filter string
+		topic  string
+		want   bool
+	}{
+		{filter: "arena/clock", topic: "arena/clock", want: true},
+		{filter: "arena/clock", topic: "arena/clock/seconds", want: false},
+		{filter: "arena/clock/seconds", topic: "arena/clock", want: false},
+		{filter: "arena/+/score", topic: "arena/home/score", want: true},
+		{filter: "arena/+/score", topic: "arena/home/fouls", want: false},
+		{filter: "arena/+", topic: "arena", want: false},
+		{filter: "arena/+", topic: "arena/", want: true},
+		{filter: "+/+", topic: "/clock", want: true},
+		{filter: "arena/#", topic: "arena", want: true},
+		{filter: "arena/#", topic: "arena/home/score", want: true},
+		{filter: "arena/#", topic: "stadium/home", want: false},
+		{filter: "#", topic: "arena/home", want: true},
+		{filter: "#", topic: "$SYS/broker/uptime", want: false},
+		{filter: "+/broker/uptime", topic: "$SYS/broker/uptime", want: false},
+		{filter: "$SYS/#", topic: "$SYS/broker/uptime", want: true},
+		{filter: "Arena/clock", topic: "arena/clock", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filter+" "+tt.topic, func(t *testing.T) {
+			if got := matchTopic(tt.filter, tt.topic); got != tt.want {
+				t.Errorf("matchTopic(%q, %q) = %v, want %v", tt.filter, tt.topic, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		key        string
+		payload    string
+		wantFilter string
+		want       any
+		wantErr    bool
+	}{
+		{key: "arena/clock", payload: "12:00", wantFilter: "arena/clock", want: "12:00"},
+		{key: "arena/clock", payload: `{"seconds":5}`, wantFilter: "arena/clock", want: `{"seconds":5}`},
+		{key: "arena/clock::seconds", payload: `{"seconds":5}`, wantFilter: "arena/clock", want: 5.0},
+		{key: "arena/+/score::teams[1].name", payload: `{"teams":[{"name":"A"},{"name":"B"}]}`, wantFilter: "arena/+/score", want: "B"},
+		{key: "arena/clock::missing", payload: `{"seconds":5}`, wantFilter: "arena/clock", want: nil},
+		{key: "arena/clock::seconds", payload: "12:00", wantFilter: "arena/clock", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			filter, expression := splitKey(tt.key)
+			if filter != tt.wantFilter {
+				t.Errorf("splitKey(%q) filter = %q, want %q", tt.key, filter, tt.wantFilter)
+			}
+			var expr *jmespath.JMESPath
+			if expression != "" {
+				expr = jmespath.MustCompile(expression)
+			}
+			got, err := evaluate(expr, []byte(tt.payload))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("evaluate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("evaluate() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package osc
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog"
+
+	d "github.com/overlayfox/caspaw-cg/src/data"
+	"github.com/overlayfox/caspaw-cg/src/types"
+)
+
+// maxPacketSize is the largest UDP payload, OSC packets are never split across datagrams
+const maxPacketSize = 65535
+
+// client runs a UDP OSC server. Location keys are OSC addresses such as "/score/home" holding the
+// arguments of the last message to that address, "/score/home#2" selects the second argument.
+// Arguments are passed on as received, the resolver converts them to the type of the field.
+type client struct {
+	logger zerolog.Logger
+
+	cfg            d.OSCDataSource
+	eventProcessor types.EventProcessor
+
+	conn net.PacketConn
+	args map[string][]any // map[address]arguments of the last message
+
+	fields *d.Fields
+	mtx    sync.RWMutex // guards args
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewClient(ctx context.Context, logger zerolog.Logger, cfg d.OSCDataSource, eventProcessor types.EventProcessor) (types.DataSource, error) {
+	conn, err := net.ListenPacket("udp", cfg.Listen)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for OSC: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	logger = logger.With().Str("component", fmt.Sprintf("osc-client-%s", cfg.Name)).Logger()
+	client := &client{
+		logger:         logger,
+		cfg:            cfg,
+		eventProcessor: eventProcessor,
+
+		conn: conn,
+		args: make(map[string][]any),
+
+		fields: d.NewFields(cfg.Name, logger, eventProcessor),
+
+		ctx:    ctx,
+		cancel: cancel,
+	}
+	client.receive() // start receiving messages
+	client.logger.Info().Str("listen", conn.LocalAddr().String()).Msg("listening for OSC messages")
+
+	return client, nil
+}
+
+func (c *client) GetName() string {
+	return c.cfg.Name
+}
+
+func (c *client) Subscribe(locations []types.Location) (string, error) {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+
+	return c.fields.Subscribe(locations, c.lookup)
+}
+
+func (c *client) Unsubscribe(handle string) error {
+	_, err := c.fields.Unsubscribe(handle)
+	return err
+}
+
+func (c *client) Get(location types.Location) (types.Data, error) {
+	return c.fields.Get(location)
+}
+
+func (c *client) Close() {
+	c.logger.Info().Msg("closing osc client")
+	c.cancel()
+	c.conn.Close() // unblocks ReadFrom
+	c.wg.Wait()
+	c.logger.Info().Msg("osc client closed")
+}
+
+func (c *client) receive() {
+	c.wg.Go(func() {
+		buf := make([]byte, maxPacketSize)
+		for {
+			n, from, err := c.conn.ReadFrom(buf)
+			if err != nil {
+				if c.ctx.Err() != nil {
+					return
+				}
+				c.logger.Error().Err(err).Msg("failed to read OSC packet")
+				continue
+			}
+
+			messages, err := parsePacket(buf[:n])
+			if err != nil {
+				c.logger.Warn().Err(err).Str("from", from.String()).Msg("dropping invalid OSC packet")
+				continue
+			}
+			c.apply(messages)
+		}
+	})
+}
+
+// apply stores the arguments of every message and immediately emits events for the subscribed keys whose value changed
+func (c *client) apply(messages []message) {
+	c.mtx.Lock()
+	received := make(map[string]bool, len(messages))
+	for _, msg := range messages {
+		c.args[msg.address] = msg.args
+		received[msg.address] = true
+	}
+	changed, err := c.fields.RefreshMatching(func(key string) bool {
+		address, _, err := parseKey(key)
+		return err == nil && received[address]
+	}, c.lookup)
+	c.mtx.Unlock()
+	if err != nil {
+		c.logger.Warn().Err(err).Msg("failed to read subscribed arguments")
+	}
+
+	c.fields.Emit(changed)
+}
+
+// lookup returns the arguments of the last message to the address of key, nothing may have been received yet.
+// The caller must hold c.mtx.
+func (c *client) lookup(key string) (any, error) {
+	address, index, err := parseKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return argumentValue(c.args[address], index), nil
+}
+
+// parseKey splits "/address#2" into the address and the 1-based argument index, 0 selects all arguments
+func parseKey(key string) (address string, index int, err error) {
+	address, indexStr, found := strings.Cut(key, "#")
+	if !strings.HasPrefix(address, "/") {
+		return "", 0, fmt.Errorf("invalid location '%s', OSC addresses start with '/'", key)
+	}
+	if !found {
+		return address, 0, nil
+	}
+	index, err = strconv.Atoi(indexStr)
+	if err != nil || index <= 0 {
+		return "", 0, fmt.Errorf("invalid argument index in location '%s', arguments start at 1", key)
+	}
+	return address, index, nil
+}
+
+// argumentValue returns the selected argument as received, blobs as text. Without an index a single
+// argument is returned as is and several are joined with spaces.
+func argumentValue(args []any, index int) any {
+	if index > 0 {
+		if index > len(args) {
+			return nil
+		}
+		args = args[index-1 : index]
+	}
+	switch len(args) {
+	case 0:
+		return nil
+	case 1:
+		if b, ok := args[0].([]byte); ok {
+			return string(b)
+		}
+		return args[0]
+	}
+
+	parts := make([]string, 0, len(args))
+	for _, arg := range args {
+		if b, ok := arg.([]byte); ok {
+			parts = append(parts, string(b))
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%v", arg))
+	}
+	return strings.Join(parts, " ")
+}
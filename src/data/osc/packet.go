@@ -0,0 +1,154 @@
+package osc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// message is a decoded OSC message, bundles are flattened into their messages
+type message struct {
+	address string
+	args    []any
+}
+
+var bundleTag = []byte("#bundle\x00")
+
+// parsePacket decodes an OSC 1.0 packet, either a single message or a (nested) bundle
+func parsePacket(packet []byte) ([]message, error) {
+	if bytes.HasPrefix(packet, bundleTag) {
+		return parseBundle(packet)
+	}
+	msg, err := parseMessage(packet)
+	if err != nil {
+		return nil, err
+	}
+	return []message{msg}, nil
+}
+
+func parseBundle(packet []byte) ([]message, error) {
+	// skip the "#bundle" tag and the time tag, messages are applied as they arrive
+	rest := packet[len(bundleTag):]
+	if len(rest) < 8 {
+		return nil, errors.New("bundle without time tag")
+	}
+	rest = rest[8:]
+
+	var messages []message
+	for len(rest) > 0 {
+		if len(rest) < 4 {
+			return nil, errors.New("truncated bundle element size")
+		}
+		size := int(binary.BigEndian.Uint32(rest))
+		rest = rest[4:]
+		if size < 0 || size > len(rest) {
+			return nil, errors.New("truncated bundle element")
+		}
+		elements, err := parsePacket(rest[:size])
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, elements...)
+		rest = rest[size:]
+	}
+	return messages, nil
+}
+
+func parseMessage(packet []byte) (message, error) {
+	address, rest, err := readString(packet)
+	if err != nil {
+		return message{}, fmt.Errorf("invalid address: %w", err)
+	}
+	if len(address) == 0 || address[0] != '/' {
+		return message{}, fmt.Errorf("invalid address '%s'", address)
+	}
+	if len(rest) == 0 {
+		return message{address: address}, nil // type tags are optional for messages without arguments
+	}
+
+	tags, rest, err := readString(rest)
+	if err != nil {
+		return message{}, fmt.Errorf("invalid type tags: %w", err)
+	}
+	if len(tags) == 0 || tags[0] != ',' {
+		return message{}, fmt.Errorf("invalid type tags '%s'", tags)
+	}
+
+	args := make([]any, 0, len(tags)-1)
+	for _, tag := range tags[1:] {
+		var arg any
+		switch tag {
+		case 'i':
+			if len(rest) < 4 {
+				return message{}, errors.New("truncated int32 argument")
+			}
+			arg, rest = int64(int32(binary.BigEndian.Uint32(rest))), rest[4:]
+		case 'f':
+			if len(rest) < 4 {
+				return message{}, errors.New("truncated float32 argument")
+			}
+			arg, rest = float64(math.Float32frombits(binary.BigEndian.Uint32(rest))), rest[4:]
+		case 'h', 't':
+			if len(rest) < 8 {
+				return message{}, errors.New("truncated int64 argument")
+			}
+			arg, rest = int64(binary.BigEndian.Uint64(rest)), rest[8:]
+		case 'd':
+			if len(rest) < 8 {
+				return message{}, errors.New("truncated float64 argument")
+			}
+			arg, rest = math.Float64frombits(binary.BigEndian.Uint64(rest)), rest[8:]
+		case 's', 'S':
+			arg, rest, err = readString(rest)
+			if err != nil {
+				return message{}, fmt.Errorf("invalid string argument: %w", err)
+			}
+		case 'b':
+			if len(rest) < 4 {
+				return message{}, errors.New("truncated blob size")
+			}
+			size := int(binary.BigEndian.Uint32(rest))
+			rest = rest[4:]
+			if size < 0 || padded(size) > len(rest) {
+				return message{}, errors.New("truncated blob argument")
+			}
+			arg, rest = bytes.Clone(rest[:size]), rest[padded(size):]
+		case 'c':
+			if len(rest) < 4 {
+				return message{}, errors.New("truncated char argument")
+			}
+			arg, rest = string(rune(binary.BigEndian.Uint32(rest))), rest[4:]
+		case 'T':
+			arg = true
+		case 'F':
+			arg = false
+		case 'N':
+			arg = nil
+		case 'I':
+			arg = math.Inf(1)
+		default:
+			return message{}, fmt.Errorf("unsupported type tag '%c'", tag)
+		}
+		args = append(args, arg)
+	}
+	return message{address: address, args: args}, nil
+}
+
+// readString reads a null-terminated string padded to a multiple of four bytes
+func readString(b []byte) (string, []byte, error) {
+	end := bytes.IndexByte(b, 0)
+	if end < 0 {
+		return "", nil, errors.New("missing string terminator")
+	}
+	next := padded(end + 1)
+	if next > len(b) {
+		return "", nil, errors.New("truncated string padding")
+	}
+	return string(b[:end]), b[next:], nil
+}
+
+func padded(n int) int {
+	return (n + 3) &^ 3
+}
@@ -0,0 +1,103 @@
+package osc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"reflect"
+	"testing"
+)
+
+// oscString encodes s null-terminated and padded to four bytes
+func oscString(s string) []byte {
+	b := make([]byte, padded(len(s)+1))
+	copy(b, s)
+	return b
+}
+
+func oscUint32(n uint32) []byte {
+	return binary.BigEndian.AppendUint32(nil, n)
+}
+
+func oscUint64(n uint64) []byte {
+	return binary.BigEndian.AppendUint64(nil, n)
+}
+
+func packet(parts ...[]byte) []byte {
+	return bytes.Join(parts, nil)
+}
+
+func bundle(elements ...[]byte) []byte {
+	b := packet(bundleTag, oscUint64(1))
+	for _, element := range elements {
+		b = packet(b, oscUint32(uint32(len(element))), element)
+	}
+	return b
+}
+
+func TestParsePacket(t *testing.T) {
+	score := packet(oscString("/score/home"), oscString(",i"), oscUint32(3))
+	clock := packet(oscString("/clock"), oscString(",sf"), oscString("12:00"), oscUint32(math.Float32bits(0.5)))
+
+	tests := []struct {
+		name    string
+		packet  []byte
+		want    []message
+		wantErr bool
+	}{
+		{name: "int", packet: score, want: []message{{address: "/score/home", args: []any{int64(3)}}}},
+		{name: "negative int", packet: packet(oscString("/a"), oscString(",i"), oscUint32(math.MaxUint32)), want: []message{{address: "/a", args: []any{int64(-1)}}}},
+		{name: "string and float", packet: clock, want: []message{{address: "/clock", args: []any{"12:00", 0.5}}}},
+		{name: "64 bit", packet: packet(oscString("/a"), oscString(",hd"), oscUint64(1<<40), oscUint64(math.Float64bits(2.25))), want: []message{{address: "/a", args: []any{int64(1 << 40), 2.25}}}},
+		{name: "blob", packet: packet(oscString("/a"), oscString(",b"), oscUint32(5), []byte("hello\x00\x00\x00")), want: []message{{address: "/a", args: []any{[]byte("hello")}}}},
+		{name: "char", packet: packet(oscString("/a"), oscString(",c"), oscUint32('x')), want: []message{{address: "/a", args: []any{"x"}}}},
+		{name: "tags without data", packet: packet(oscString("/a"), oscString(",TFNI")), want: []message{{address: "/a", args: []any{true, false, nil, math.Inf(1)}}}},
+		{name: "no type tags", packet: oscString("/ping"), want: []message{{address: "/ping"}}},
+		{name: "no arguments", packet: packet(oscString("/ping"), oscString(",")), want: []message{{address: "/ping", args: []any{}}}},
+		{name: "bundle", packet: bundle(score, clock), want: []message{{address: "/score/home", args: []any{int64(3)}}, {address: "/clock", args: []any{"12:00", 0.5}}}},
+		{name: "nested bundle", packet: bundle(bundle(score)), want: []message{{address: "/score/home", args: []any{int64(3)}}}},
+		{name: "empty bundle", packet: bundle(), want: nil},
+		{name: "address without slash", packet: oscString("score"), wantErr: true},
+		{name: "unterminated address", packet: []byte("/abc"), wantErr: true},
+		{name: "type tags without comma", packet: packet(oscString("/a"), oscString("i"), oscUint32(1)), wantErr: true},
+		{name: "truncated int", packet: packet(oscString("/a"), oscString(",i"), []byte{0, 0}), wantErr: true},
+		{name: "truncated blob", packet: packet(oscString("/a"), oscString(",b"), oscUint32(8), []byte("abcd")), wantErr: true},
+		{name: "unsupported tag", packet: packet(oscString("/a"), oscString(",m"), oscUint32(1)), wantErr: true},
+		{name: "bundle without time tag", packet: packet(bundleTag, []byte{0, 0}), wantErr: true},
+		{name: "truncated bundle element", packet: packet(bundleTag, oscUint64(1), oscUint32(64), score), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parsePacket(tt.packet)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parsePacket() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parsePacket() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestArgumentValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		args  []any
+		index int
+		want  any
+	}{
+		{name: "none", args: nil, want: nil},
+		{name: "single keeps its type", args: []any{int64(3)}, want: int64(3)},
+		{name: "single blob", args: []any{[]byte("hi")}, want: "hi"},
+		{name: "joined", args: []any{"home", int64(3), []byte("x")}, want: "home 3 x"},
+		{name: "selected", args: []any{"home", 0.5}, index: 2, want: 0.5},
+		{name: "selected out of range", args: []any{"home"}, index: 2, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := argumentValue(tt.args, tt.index); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("argumentValue() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
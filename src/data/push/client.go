@@ -0,0 +1,253 @@
+package push
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/jmespath/go-jmespath"
+	"github.com/rs/zerolog"
+
+	d "github.com/overlayfox/caspaw-cg/src/data"
+	"github.com/overlayfox/caspaw-cg/src/types"
+)
+
+// minReconnectDelay is the first backoff step after a feed connection dropped
+const minReconnectDelay = 500 * time.Millisecond
+
+// client keeps a WebSocket or Server-Sent-Events connection open and applies every JSON message
+// to an in-memory document, location keys are JMESPath expressions evaluated against that document.
+type client struct {
+	logger zerolog.Logger
+
+	cfg            d.PushDataSource
+	eventProcessor types.EventProcessor
+
+	document    any
+	lastMessage time.Time
+	stale       bool
+	lastRead    time.Time          // last message or (re)connect, a feed quiet for StaleAfter since is reconnected
+	disconnect  context.CancelFunc // drops the current connection, nil while not connected
+
+	fields      *d.Fields
+	expressions map[string]*jmespath.JMESPath // map[key]compiled expression
+	mtx         sync.RWMutex
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewClient(ctx context.Context, logger zerolog.Logger, cfg d.PushDataSource, eventProcessor types.EventProcessor) (types.DataSource, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid feed url: %w", err)
+	}
+
+	var feed feed
+	switch u.Scheme {
+	case "ws", "wss":
+		feed = websocketFeed
+	case "http", "https":
+		feed = sseFeed
+	default:
+		return nil, fmt.Errorf("unsupported feed url scheme: %s", u.Scheme)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	logger = logger.With().Str("component", fmt.Sprintf("push-client-%s", cfg.Name)).Logger()
+	client := &client{
+		logger:         logger,
+		cfg:            cfg,
+		eventProcessor: eventProcessor,
+
+		lastMessage: time.Now(),
+
+		fields:      d.NewFields(cfg.Name, logger, eventProcessor),
+		expressions: make(map[string]*jmespath.JMESPath),
+
+		ctx:    ctx,
+		cancel: cancel,
+	}
+	client.connect(feed) // start receiving messages
+	if cfg.StaleAfter > 0 {
+		client.watchStaleness()
+	}
+
+	return client, nil
+}
+
+func (c *client) GetName() string {
+	return c.cfg.Name
+}
+
+// Subscribe accepts locations before the feed delivered anything, their values are pushed once it does
+func (c *client) Subscribe(locations []types.Location) (string, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	return c.fields.Subscribe(locations, c.evaluate)
+}
+
+func (c *client) Unsubscribe(handle string) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	keys, err := c.fields.Unsubscribe(handle)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		delete(c.expressions, key)
+	}
+	return nil
+}
+
+func (c *client) Get(location types.Location) (types.Data, error) {
+	return c.fields.Get(location)
+}
+
+func (c *client) Close() {
+	c.logger.Info().Msg("closing push client")
+	c.cancel()
+	c.wg.Wait()
+	c.logger.Info().Msg("push client closed")
+}
+
+// connect keeps the feed connected, reconnecting with an exponential backoff whenever it drops
+func (c *client) connect(feed feed) {
+	c.wg.Go(func() {
+		delay := minReconnectDelay
+		for {
+			ctx, cancel := context.WithCancel(c.ctx)
+			c.mtx.Lock()
+			c.lastRead = time.Now()
+			c.disconnect = cancel
+			c.mtx.Unlock()
+
+			connected := false
+			err := feed(ctx, c.cfg, func(message []byte) {
+				connected = true
+				c.apply(message)
+			})
+
+			c.mtx.Lock()
+			c.disconnect = nil
+			c.mtx.Unlock()
+			cancel()
+			if c.ctx.Err() != nil {
+				return
+			}
+			if connected {
+				delay = minReconnectDelay // the connection was healthy, start over with short delays
+			}
+			c.logger.Warn().Err(err).Dur("retryIn", delay).Msg("feed disconnected")
+
+			select {
+			case <-time.After(delay):
+			case <-c.ctx.Done():
+				return
+			}
+			delay = min(delay*2, c.cfg.MaxReconnectDelay)
+		}
+	})
+}
+
+// apply merges a message into the document and immediately emits events for the primed keys whose value changed
+func (c *client) apply(message []byte) {
+	var patch any
+	if err := json.Unmarshal(message, &patch); err != nil {
+		c.logger.Error().Err(err).Msg("failed to decode feed message")
+		return
+	}
+
+	c.mtx.Lock()
+	if c.cfg.MessageMode == d.PushMessageModeMergePatch {
+		c.document = mergePatch(c.document, patch)
+	} else {
+		c.document = patch
+	}
+	c.lastMessage = time.Now()
+	c.lastRead = c.lastMessage
+	wasStale := c.stale
+	c.stale = false
+
+	changed, err := c.fields.Refresh(c.evaluate)
+	c.mtx.Unlock()
+	if err != nil {
+		c.logger.Error().Err(err).Msg("failed to evaluate expressions")
+	}
+
+	if wasStale {
+		c.logger.Info().Msg("feed resumed")
+		c.pushEvent(types.DataSourceStale{Name: c.cfg.Name, Stale: false})
+	}
+	c.fields.Emit(changed)
+}
+
+// evaluate applies the JMESPath expression key to the document, compiling it on first use. Keys are
+// empty until the feed delivered its first message. The caller must hold c.mtx for writing.
+func (c *client) evaluate(key string) (any, error) {
+	expr, ok := c.expressions[key]
+	if !ok {
+		var err error
+		if expr, err = jmespath.Compile(key); err != nil {
+			return nil, fmt.Errorf("invalid JMESPath expression '%s': %w", key, err)
+		}
+		c.expressions[key] = expr
+	}
+	if c.document == nil {
+		return nil, nil
+	}
+	value, err := expr.Search(c.document)
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate '%s': %w", key, err)
+	}
+	return value, nil
+}
+
+// watchStaleness flags the datasource as stale once no message arrived for StaleAfter and drops the
+// connection, a feed that went quiet is often connected to a server that is long gone
+func (c *client) watchStaleness() {
+	c.wg.Go(func() {
+		ticker := time.NewTicker(max(c.cfg.StaleAfter/4, 100*time.Millisecond))
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-c.ctx.Done():
+				return
+			case <-ticker.C:
+				c.mtx.Lock()
+				becameStale := !c.stale && time.Since(c.lastMessage) > c.cfg.StaleAfter
+				if becameStale {
+					c.stale = true
+				}
+				var disconnect context.CancelFunc
+				if c.disconnect != nil && time.Since(c.lastRead) > c.cfg.StaleAfter {
+					disconnect = c.disconnect
+					c.disconnect = nil
+				}
+				c.mtx.Unlock()
+
+				if disconnect != nil {
+					c.logger.Warn().Dur("staleAfter", c.cfg.StaleAfter).Msg("feed went quiet, reconnecting")
+					disconnect()
+				}
+				if becameStale {
+					c.logger.Warn().Dur("staleAfter", c.cfg.StaleAfter).Msg("feed went quiet, flagging datasource as stale")
+					c.pushEvent(types.DataSourceStale{Name: c.cfg.Name, Stale: true})
+				}
+			}
+		}
+	})
+}
+
+func (c *client) pushEvent(event types.Event) {
+	if err := c.eventProcessor.Push(event); err != nil {
+		c.logger.Error().Err(err).Str("identifier", string(event.GetIdentifier())).Msg("failed to emit datasource event")
+	}
+}
@@ -0,0 +1,133 @@
+package push
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	d "github.com/overlayfox/caspaw-cg/src/data"
+)
+
+const (
+	// pingInterval is how often WebSocket connections are pinged, a connection that answers neither with a
+	// pong nor with a message within pongTimeout is dropped and reconnected
+	pingInterval = 15 * time.Second
+	pongTimeout  = 2 * pingInterval
+	// sseIdleTimeout drops event streams that sent nothing, not even a keep-alive comment, for this long
+	sseIdleTimeout = time.Minute
+)
+
+// feed connects to a push source and calls onMessage for every received message until the
+// connection drops or ctx is cancelled. It always returns a non-nil error describing why it stopped.
+type feed func(ctx context.Context, cfg d.PushDataSource, onMessage func(message []byte)) error
+
+func websocketFeed(ctx context.Context, cfg d.PushDataSource, onMessage func(message []byte)) error {
+	header := http.Header{}
+	for name, value := range cfg.Headers {
+		header.Set(name, value)
+	}
+
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, cfg.URL, header)
+	if err != nil {
+		return fmt.Errorf("failed to connect to websocket: %w", err)
+	}
+	defer conn.Close()
+
+	// unblock ReadMessage once the datasource is closed or the connection is dropped as stale
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	// a half-open connection never errors on its own, every pong or message extends the read deadline
+	conn.SetReadDeadline(time.Now().Add(pongTimeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(pongTimeout))
+	})
+	pingCtx, stopPing := context.WithCancel(ctx)
+	defer stopPing()
+	go func() {
+		ticker := time.NewTicker(pingInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-pingCtx.Done():
+				return
+			case <-ticker.C:
+				// WriteControl may be called concurrently with ReadMessage
+				if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(pingInterval)); err != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	for {
+		messageType, message, err := conn.ReadMessage()
+		if err != nil {
+			return fmt.Errorf("failed to read websocket message: %w", err)
+		}
+		conn.SetReadDeadline(time.Now().Add(pongTimeout))
+		if messageType != websocket.TextMessage && messageType != websocket.BinaryMessage {
+			continue
+		}
+		onMessage(message)
+	}
+}
+
+func sseFeed(ctx context.Context, cfg d.PushDataSource, onMessage func(message []byte)) error {
+	// a half-open connection never errors on its own, every line received resets the idle timer
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	idle := time.AfterFunc(sseIdleTimeout, cancel)
+	defer idle.Stop()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, cfg.URL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Cache-Control", "no-cache")
+	for name, value := range cfg.Headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to connect to event stream: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code: %s", resp.Status)
+	}
+
+	// an event is made of one or more "data:" lines and ends with an empty line
+	var data [][]byte
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		idle.Reset(sseIdleTimeout)
+		line := scanner.Bytes()
+		switch {
+		case len(line) == 0:
+			if len(data) > 0 {
+				onMessage(bytes.Join(data, []byte("\n")))
+				data = nil
+			}
+		case bytes.HasPrefix(line, []byte("data:")):
+			value := bytes.TrimPrefix(bytes.TrimPrefix(line, []byte("data:")), []byte(" "))
+			data = append(data, bytes.Clone(value))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		if !idle.Stop() && ctx.Err() != nil {
+			return fmt.Errorf("event stream sent nothing for %s", sseIdleTimeout)
+		}
+		return fmt.Errorf("failed to read event stream: %w", err)
+	}
+	return errors.New("event stream closed by server")
+}
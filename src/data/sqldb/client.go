@@ -0,0 +1,372 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	_ "modernc.org/sqlite" // registers the "sqlite" driver
+
+	d "github.com/overlayfox/caspaw-cg/src/data"
+	"github.com/overlayfox/caspaw-cg/src/types"
+)
+
+// reloadDebounce groups the burst of writes a single transaction produces on the database and its journal
+const reloadDebounce = 200 * time.Millisecond
+
+// queryResult holds the rows of a named query, each row has one value per column
+type queryResult struct {
+	columns []string
+	rows    [][]any
+}
+
+// client runs named queries against a SQL database. Location keys address a cell of a query result,
+// either as "query.row.column" (1-based row, column by name), A1-style as "'query'!B2"
+// (column by position, row 1 is the first result row) so a result column can be used as a types.Range,
+// or by content as "'query'!name[id=17]".
+type client struct {
+	logger zerolog.Logger
+
+	cfg               d.SQLDataSource
+	eventProcessor    types.EventProcessor
+	datasourceManager types.DatasourceManager
+
+	db      *sql.DB
+	results map[string]queryResult // map[query]result
+
+	fields *d.Fields
+	mtx    sync.RWMutex // guards results
+
+	params  map[string]string // map[source]handle of the subscription to the parameters it provides
+	trigger chan struct{}
+	events  <-chan types.Event
+	watcher *d.FileWatcher // nil if the database is not a watchable file
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewClient(ctx context.Context, logger zerolog.Logger, cfg d.SQLDataSource, eventProcessor types.EventProcessor, datasourceManager types.DatasourceManager) (types.DataSource, error) {
+	db, err := sql.Open(cfg.Driver, cfg.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	logger = logger.With().Str("component", fmt.Sprintf("sql-client-%s", cfg.Name)).Logger()
+	client := &client{
+		logger:            logger,
+		cfg:               cfg,
+		eventProcessor:    eventProcessor,
+		datasourceManager: datasourceManager,
+
+		db:      db,
+		results: make(map[string]queryResult, len(cfg.Queries)),
+
+		fields: d.NewFields(cfg.Name, logger, eventProcessor),
+
+		params:  make(map[string]string),
+		trigger: make(chan struct{}, 1),
+
+		ctx:    ctx,
+		cancel: cancel,
+	}
+
+	if path := databaseFile(cfg.DSN); path != "" {
+		// a transaction writes the database and its journal, re-run the queries once for both
+		watched := []string{path, path + "-wal", path + "-journal"}
+		watcher, err := d.WatchFiles(ctx, client.logger, watched, reloadDebounce, 0, func() error {
+			client.requestRun()
+			return nil
+		})
+		if err != nil {
+			cancel()
+			db.Close()
+			return nil, err
+		}
+		client.watcher = watcher
+	}
+
+	return client, nil
+}
+
+// Start subscribes the parameters of the queries on their datasources and runs the queries for the
+// first time, the datasources providing parameters may be registered after this one
+func (c *client) Start() error {
+	keys := make(map[string][]types.Location) // map[source]parameter locations
+	for _, query := range c.cfg.Queries {
+		for _, param := range query.Params {
+			keys[param.Source] = append(keys[param.Source], types.Location{Key: param.Key})
+		}
+	}
+	for source, locations := range keys {
+		ds, err := c.datasourceManager.GetDataSource(source)
+		if err != nil {
+			return fmt.Errorf("failed to resolve parameters of '%s': %w", c.cfg.Name, err)
+		}
+		handle, err := ds.Subscribe(locations)
+		if err != nil {
+			return fmt.Errorf("failed to subscribe parameters of '%s' on '%s': %w", c.cfg.Name, source, err)
+		}
+		c.params[source] = handle
+	}
+
+	c.runQueries()
+	c.listenForParams()
+	c.updateDataFields() // start update cycle
+	return nil
+}
+
+func (c *client) GetName() string {
+	return c.cfg.Name
+}
+
+func (c *client) Subscribe(locations []types.Location) (string, error) {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+
+	return c.fields.Subscribe(locations, c.lookup)
+}
+
+func (c *client) Unsubscribe(handle string) error {
+	_, err := c.fields.Unsubscribe(handle)
+	return err
+}
+
+func (c *client) Get(location types.Location) (types.Data, error) {
+	return c.fields.Get(location)
+}
+
+func (c *client) Close() {
+	c.logger.Info().Msg("closing sql client")
+	// stop listening first, the listener has to keep draining until the processor released the channel
+	if c.events != nil {
+		c.eventProcessor.CloseChannel(c.events)
+	}
+	for source, handle := range c.params {
+		ds, err := c.datasourceManager.GetDataSource(source)
+		if err != nil {
+			continue // already closed
+		}
+		if err := ds.Unsubscribe(handle); err != nil {
+			c.logger.Warn().Err(err).Str("source", source).Msg("failed to unsubscribe query parameters")
+		}
+	}
+	c.cancel()
+	if c.watcher != nil {
+		c.watcher.Close()
+	}
+	c.wg.Wait()
+	c.db.Close()
+	c.logger.Info().Msg("sql client closed")
+}
+
+// lookup resolves a location key against the latest query results, cells outside a result are empty.
+// The caller must hold c.mtx.
+func (c *client) lookup(key string) (any, error) {
+	if types.IsRecordKey(key) {
+		record, err := types.ParseRecordKey(key)
+		if err != nil {
+			return nil, err
+		}
+		result, ok := c.results[record.Sheet]
+		if !ok {
+			return nil, fmt.Errorf("unknown query '%s' in location '%s'", record.Sheet, key)
+		}
+		// the result columns act as the header row
+		header := make([]any, 0, len(result.columns))
+		for _, column := range result.columns {
+			header = append(header, column)
+		}
+		return types.FindRecord(record, append([][]any{header}, result.rows...))
+	}
+
+	query, row, column, columnName, err := parseKey(key)
+	if err != nil {
+		return nil, err
+	}
+	result, ok := c.results[query]
+	if !ok {
+		return nil, fmt.Errorf("unknown query '%s' in location '%s'", query, key)
+	}
+
+	if columnName != "" {
+		column = slices.Index(result.columns, columnName) + 1
+		if column == 0 {
+			return nil, fmt.Errorf("unknown column '%s' in location '%s'", columnName, key)
+		}
+	}
+	if row > len(result.rows) || column > len(result.columns) {
+		return nil, nil
+	}
+	return result.rows[row-1][column-1], nil
+}
+
+// parseKey splits "query.row.column" or "'query'!B2" into the query name, the 1-based row and
+// either the 1-based column position or the column name.
+func parseKey(key string) (query string, row int, column int, columnName string, err error) {
+	if strings.Contains(key, "!") {
+		cell, err := types.ParseCell(key)
+		if err != nil {
+			return "", 0, 0, "", err
+		}
+		return cell.Sheet, cell.Row, cell.Column, "", nil
+	}
+
+	parts := strings.SplitN(key, ".", 3)
+	if len(parts) != 3 {
+		return "", 0, 0, "", fmt.Errorf("invalid location format '%s', use 'query.row.column' or 'query!A1'", key)
+	}
+	row, err = strconv.Atoi(parts[1])
+	if err != nil || row <= 0 {
+		return "", 0, 0, "", fmt.Errorf("invalid row in location '%s', rows start at 1", key)
+	}
+	return parts[0], row, 0, parts[2], nil
+}
+
+// databaseFile returns the file behind a sqlite DSN such as "stats.db" or "file:stats.db?mode=ro",
+// or an empty string for in-memory databases.
+func databaseFile(dsn string) string {
+	path := strings.TrimPrefix(dsn, "file:")
+	if i := strings.Index(path, "?"); i >= 0 {
+		path = path[:i]
+	}
+	if path == "" || path == ":memory:" {
+		return ""
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return ""
+	}
+	return absPath
+}
+
+// runQueries re-runs every query and emits events for the subscribed cells whose value changed.
+// A failing query keeps its previous result.
+func (c *client) runQueries() {
+	results := make(map[string]queryResult, len(c.cfg.Queries))
+	for _, query := range c.cfg.Queries {
+		result, err := c.runQuery(query)
+		if err != nil {
+			c.logger.Error().Err(err).Str("query", query.Name).Msg("failed to run query")
+			continue
+		}
+		results[query.Name] = result
+	}
+
+	c.mtx.Lock()
+	for name, result := range results {
+		c.results[name] = result
+	}
+	changed, _ := c.fields.Refresh(c.lookup)
+	c.mtx.Unlock()
+
+	c.fields.Emit(changed)
+}
+
+func (c *client) runQuery(query d.SQLQuery) (queryResult, error) {
+	args := make([]any, 0, len(query.Params))
+	for _, param := range query.Params {
+		ds, err := c.datasourceManager.GetDataSource(param.Source)
+		if err != nil {
+			return queryResult{}, fmt.Errorf("failed to resolve parameter: %w", err)
+		}
+		data, err := ds.Get(types.Location{Key: param.Key})
+		if err != nil {
+			return queryResult{}, fmt.Errorf("failed to resolve parameter from '%s': %w", param.Source, err)
+		}
+		args = append(args, data.Value)
+	}
+
+	rows, err := c.db.QueryContext(c.ctx, query.Query, args...)
+	if err != nil {
+		return queryResult{}, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return queryResult{}, err
+	}
+
+	result := queryResult{columns: columns}
+	for rows.Next() {
+		values := make([]any, len(columns))
+		pointers := make([]any, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return queryResult{}, err
+		}
+		for i, value := range values {
+			if b, ok := value.([]byte); ok {
+				values[i] = string(b)
+			}
+		}
+		result.rows = append(result.rows, values)
+	}
+	return result, rows.Err()
+}
+
+// requestRun schedules a run of all queries without blocking, runs requested while one is pending are merged
+func (c *client) requestRun() {
+	select {
+	case c.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// listenForParams re-runs the queries as soon as a value one of them takes as a parameter changes
+func (c *client) listenForParams() {
+	params := make(map[d.SQLQueryParam]bool)
+	for _, query := range c.cfg.Queries {
+		for _, param := range query.Params {
+			params[param] = true
+		}
+	}
+
+	c.events = c.eventProcessor.Listen()
+	c.wg.Go(func() {
+		for event := range c.events {
+			update, ok := event.(types.DataSourceValueUpdate)
+			if ok && params[d.SQLQueryParam{Source: update.Source, Key: update.LocationKey}] {
+				c.requestRun()
+			}
+		}
+	})
+}
+
+func (c *client) updateDataFields() {
+	c.wg.Go(func() {
+		var interval <-chan time.Time
+		if c.cfg.PollInterval > 0 {
+			ticker := time.NewTicker(c.cfg.PollInterval)
+			defer ticker.Stop()
+			interval = ticker.C
+		}
+
+		for {
+			select {
+			case <-c.ctx.Done():
+				return
+			case <-interval:
+				c.runQueries()
+			case <-c.trigger:
+				c.runQueries()
+			}
+		}
+	})
+}
@@ -0,0 +1,84 @@
+package data
+
+import (
+	"fmt"
+	"slices"
+
+	guuid "github.com/google/uuid"
+
+	"github.com/overlayfox/caspaw-cg/src/types"
+)
+
+// Subscriptions reference counts the locations subscribed to a datasource, a location stays primed until
+// every subscription holding it was removed. Locations are counted per types.Location.Variant, so the
+// formatted and the unformatted value of a cell are primed independently. It is not safe for concurrent
+// use, Fields guards it with the mutex of its data fields.
+type Subscriptions struct {
+	handles map[string][]types.Location // map[handle]variants of the subscription
+	refs    map[types.Location]int      // map[variant]number of subscriptions holding the variant
+}
+
+func NewSubscriptions() *Subscriptions {
+	return &Subscriptions{
+		handles: make(map[string][]types.Location),
+		refs:    make(map[types.Location]int),
+	}
+}
+
+// Add registers a subscription to fields and returns its handle and the fields no other subscription
+// held yet, which the datasource adds to its data fields. A location subscribed again keeps the type it
+// was first subscribed with.
+func (s *Subscriptions) Add(fields []*types.Data) (string, []*types.Data) {
+	handle := guuid.NewString()
+	variants := make([]types.Location, 0, len(fields))
+	added := make([]*types.Data, 0, len(fields))
+	for _, field := range fields {
+		variant := field.Variant()
+		if slices.Contains(variants, variant) {
+			continue
+		}
+		variants = append(variants, variant)
+		s.refs[variant]++
+		if s.refs[variant] == 1 {
+			added = append(added, field)
+		}
+	}
+	s.handles[handle] = variants
+	return handle, added
+}
+
+// Remove drops a subscription and returns the variants no subscription holds anymore
+func (s *Subscriptions) Remove(handle string) ([]types.Location, error) {
+	variants, ok := s.handles[handle]
+	if !ok {
+		return nil, fmt.Errorf("subscription '%s' not found", handle)
+	}
+	delete(s.handles, handle)
+
+	removed := make([]types.Location, 0, len(variants))
+	for _, variant := range variants {
+		s.refs[variant]--
+		if s.refs[variant] <= 0 {
+			delete(s.refs, variant)
+			removed = append(removed, variant)
+		}
+	}
+	return removed, nil
+}
+
+// Holds reports whether any subscription holds a variant of key
+func (s *Subscriptions) Holds(key string) bool {
+	for variant := range s.refs {
+		if variant.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// RemoveFields returns fields without the data of variants
+func RemoveFields(fields []*types.Data, variants []types.Location) []*types.Data {
+	return slices.DeleteFunc(fields, func(data *types.Data) bool {
+		return slices.Contains(variants, data.Variant())
+	})
+}
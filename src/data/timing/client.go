@@ -0,0 +1,244 @@
+package timing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	_ "time/tzdata" // timezones must resolve on machines without a system timezone database
+
+	"github.com/rs/zerolog"
+
+	d "github.com/overlayfox/caspaw-cg/src/data"
+	"github.com/overlayfox/caspaw-cg/src/types"
+)
+
+type clock struct {
+	format string
+	loc    *time.Location
+}
+
+type stopwatch struct {
+	format    string
+	running   bool
+	startedAt time.Time
+	elapsed   time.Duration // accumulated up to startedAt
+}
+
+func (s *stopwatch) current(now time.Time) time.Duration {
+	if s.running {
+		return s.elapsed + now.Sub(s.startedAt)
+	}
+	return s.elapsed
+}
+
+// client serves clocks, countdowns and stopwatches, location keys are their configured names.
+// Values are formatted strings computed on every Get and pushed once per second or once per frame.
+type client struct {
+	logger zerolog.Logger
+
+	cfg            d.TimingDataSource
+	eventProcessor types.EventProcessor
+
+	clocks      map[string]clock
+	countdowns  map[string]d.TimingCountdown
+	stopwatches map[string]*stopwatch
+
+	fields *d.Fields
+	mtx    sync.RWMutex // guards the stopwatches
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewClient(ctx context.Context, logger zerolog.Logger, cfg d.TimingDataSource, eventProcessor types.EventProcessor) (types.TimingDataSource, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	logger = logger.With().Str("component", fmt.Sprintf("timing-client-%s", cfg.Name)).Logger()
+	client := &client{
+		logger:         logger,
+		cfg:            cfg,
+		eventProcessor: eventProcessor,
+
+		clocks:      make(map[string]clock, len(cfg.Clocks)),
+		countdowns:  make(map[string]d.TimingCountdown, len(cfg.Countdowns)),
+		stopwatches: make(map[string]*stopwatch, len(cfg.Stopwatches)),
+
+		fields: d.NewFields(cfg.Name, logger, eventProcessor),
+
+		ctx:    ctx,
+		cancel: cancel,
+	}
+
+	for _, c := range cfg.Clocks {
+		loc := time.Local
+		if c.Timezone != "" {
+			var err error
+			if loc, err = time.LoadLocation(c.Timezone); err != nil {
+				cancel()
+				return nil, fmt.Errorf("invalid timezone for clock '%s': %w", c.Name, err)
+			}
+		}
+		client.clocks[c.Name] = clock{format: c.Format, loc: loc}
+	}
+	for _, c := range cfg.Countdowns {
+		client.countdowns[c.Name] = c
+	}
+	for _, s := range cfg.Stopwatches {
+		client.stopwatches[s.Name] = &stopwatch{format: s.Format}
+	}
+	client.updateDataFields() // start update cycle
+
+	return client, nil
+}
+
+func (c *client) GetName() string {
+	return c.cfg.Name
+}
+
+func (c *client) Subscribe(locations []types.Location) (string, error) {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+
+	now := time.Now()
+	return c.fields.Subscribe(locations, func(key string) (any, error) {
+		return c.value(key, now)
+	})
+}
+
+func (c *client) Unsubscribe(handle string) error {
+	_, err := c.fields.Unsubscribe(handle)
+	return err
+}
+
+// Get computes the value at the time of the call, so it is exact even between two pushed updates
+func (c *client) Get(location types.Location) (types.Data, error) {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+
+	value, err := c.value(location.Key, time.Now())
+	if err != nil {
+		return types.Data{}, err
+	}
+
+	data, err := c.fields.Get(location)
+	if err != nil { // not subscribed, the value is computed all the same
+		data = types.Data{Location: location}
+	}
+	data.Value = value
+	return data, nil
+}
+
+func (c *client) StartStopwatch(name string) error {
+	return c.updateStopwatch(name, func(s *stopwatch, now time.Time) {
+		if !s.running {
+			s.running = true
+			s.startedAt = now
+		}
+	})
+}
+
+func (c *client) StopStopwatch(name string) error {
+	return c.updateStopwatch(name, func(s *stopwatch, now time.Time) {
+		s.elapsed = s.current(now)
+		s.running = false
+	})
+}
+
+func (c *client) ResetStopwatch(name string) error {
+	return c.updateStopwatch(name, func(s *stopwatch, now time.Time) {
+		s.elapsed = 0
+		s.startedAt = now
+	})
+}
+
+func (c *client) SetStopwatch(name string, elapsed time.Duration) error {
+	if elapsed < 0 {
+		return errors.New("elapsed time must be positive")
+	}
+	return c.updateStopwatch(name, func(s *stopwatch, now time.Time) {
+		s.elapsed = elapsed
+		s.startedAt = now
+	})
+}
+
+func (c *client) Close() {
+	c.logger.Info().Msg("closing timing client")
+	c.cancel()
+	c.wg.Wait()
+	c.logger.Info().Msg("timing client closed")
+}
+
+// updateStopwatch applies change to a stopwatch and pushes the new value right away instead of on the next tick
+func (c *client) updateStopwatch(name string, change func(s *stopwatch, now time.Time)) error {
+	c.mtx.Lock()
+	s, ok := c.stopwatches[name]
+	if !ok {
+		c.mtx.Unlock()
+		return fmt.Errorf("stopwatch '%s' not found", name)
+	}
+	change(s, time.Now())
+	c.mtx.Unlock()
+
+	c.refresh()
+	return nil
+}
+
+// value formats the clock, countdown or stopwatch name at now. The caller must hold c.mtx.
+func (c *client) value(name string, now time.Time) (any, error) {
+	if clock, ok := c.clocks[name]; ok {
+		return now.In(clock.loc).Format(clock.format), nil
+	}
+	if countdown, ok := c.countdowns[name]; ok {
+		target, err := countdown.TargetTime(now)
+		if err != nil {
+			return nil, err
+		}
+		remaining := max(target.Sub(now), 0)
+		if !strings.Contains(countdown.Format, "ff") {
+			// round up so the countdown shows 00:00:00 exactly at the target instead of a second early
+			remaining = (remaining + time.Second - 1).Truncate(time.Second)
+		}
+		return formatDuration(remaining, countdown.Format, c.cfg.FrameRate), nil
+	}
+	if s, ok := c.stopwatches[name]; ok {
+		return formatDuration(s.current(now), s.format, c.cfg.FrameRate), nil
+	}
+	return nil, fmt.Errorf("no clock, countdown or stopwatch named '%s'", name)
+}
+
+// refresh emits events for the subscribed values that changed since the last refresh
+func (c *client) refresh() {
+	now := time.Now()
+
+	c.mtx.RLock()
+	changed, _ := c.fields.Refresh(func(key string) (any, error) {
+		return c.value(key, now)
+	})
+	c.mtx.RUnlock()
+
+	c.fields.Emit(changed)
+}
+
+func (c *client) updateDataFields() {
+	interval := time.Second
+	if c.cfg.Rate == d.TimingRateFrame {
+		interval = time.Duration(float64(time.Second) / c.cfg.FrameRate)
+	}
+
+	c.wg.Go(func() {
+		for {
+			// align every tick to the interval so clocks flip on the second rather than somewhere within it
+			timer := time.NewTimer(time.Until(time.Now().Truncate(interval).Add(interval)))
+			select {
+			case <-c.ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+				c.refresh()
+			}
+		}
+	})
+}
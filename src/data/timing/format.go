@@ -0,0 +1,43 @@
+package timing
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// formatDuration renders d with the hh, mm, ss and ff (frames) tokens of format.
+// The largest token present carries the overflow, so "mm:ss" shows 90 minutes as "90:00".
+func formatDuration(d time.Duration, format string, frameRate float64) string {
+	sign := ""
+	if d < 0 {
+		sign = "-"
+		d = -d
+	}
+
+	hasHours := strings.Contains(format, "hh")
+	hasMinutes := strings.Contains(format, "mm")
+	hasSeconds := strings.Contains(format, "ss")
+
+	hours := int64(d / time.Hour)
+	minutes := int64(d / time.Minute)
+	if hasHours {
+		minutes %= 60
+	}
+	seconds := int64(d / time.Second)
+	if hasHours || hasMinutes {
+		seconds %= 60
+	}
+	frames := int64((d % time.Second).Seconds() * frameRate)
+	if !hasHours && !hasMinutes && !hasSeconds {
+		frames = int64(d.Seconds() * frameRate)
+	}
+
+	replacer := strings.NewReplacer(
+		"hh", fmt.Sprintf("%02d", hours),
+		"mm", fmt.Sprintf("%02d", minutes),
+		"ss", fmt.Sprintf("%02d", seconds),
+		"ff", fmt.Sprintf("%02d", frames),
+	)
+	return sign + replacer.Replace(format)
+}
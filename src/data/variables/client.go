@@ -0,0 +1,215 @@
+package variables
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog"
+
+	d "github.com/overlayfox/caspaw-cg/src/data"
+	"github.com/overlayfox/caspaw-cg/src/types"
+)
+
+// client holds key/value pairs edited by the operator and persists them as a JSON object,
+// location keys are the variable names.
+type client struct {
+	logger zerolog.Logger
+
+	cfg            d.VariablesDataSource
+	eventProcessor types.EventProcessor
+
+	values map[string]any
+	fields *d.Fields
+	mtx    sync.RWMutex // guards values
+}
+
+func NewClient(logger zerolog.Logger, cfg d.VariablesDataSource, eventProcessor types.EventProcessor) (types.VariablesDataSource, error) {
+	logger = logger.With().Str("component", fmt.Sprintf("variables-client-%s", cfg.Name)).Logger()
+	client := &client{
+		logger:         logger,
+		cfg:            cfg,
+		eventProcessor: eventProcessor,
+
+		values: make(map[string]any),
+		fields: d.NewFields(cfg.Name, logger, eventProcessor),
+	}
+
+	content, err := os.ReadFile(cfg.FilePath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read variables file: %w", err)
+	}
+	if len(content) > 0 {
+		if err := json.Unmarshal(content, &client.values); err != nil {
+			return nil, fmt.Errorf("failed to decode variables file: %w", err)
+		}
+	}
+
+	return client, nil
+}
+
+func (c *client) GetName() string {
+	return c.cfg.Name
+}
+
+// Subscribe accepts variables that do not exist yet, so layouts can reference them before the operator sets them
+func (c *client) Subscribe(locations []types.Location) (string, error) {
+	normalized := make([]types.Location, len(locations))
+	for i, location := range locations {
+		location.Key = normalizeKey(location.Key)
+		normalized[i] = location
+	}
+
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+
+	return c.fields.Subscribe(normalized, c.lookup)
+}
+
+func (c *client) Unsubscribe(handle string) error {
+	_, err := c.fields.Unsubscribe(handle)
+	return err
+}
+
+// Get returns any variable that is set, subscribed or not, subscribed variables that are not set are empty
+func (c *client) Get(location types.Location) (types.Data, error) {
+	location.Key = normalizeKey(location.Key)
+
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+
+	value, ok := c.values[location.Key]
+	if data, err := c.fields.Get(location); err == nil {
+		return types.Data{Location: data.Location, Value: value}, nil
+	}
+	if !ok {
+		return types.Data{}, fmt.Errorf("no data found for key: '%s'", location.Key)
+	}
+	return types.Data{Location: location, Value: value}, nil
+}
+
+func (c *client) Set(key string, value any) error {
+	_, err := c.Modify(key, func(any) (any, error) {
+		return value, nil
+	})
+	return err
+}
+
+// Modify writes the value fn returns for the current value of a variable, variables that are not set are empty
+func (c *client) Modify(key string, fn func(current any) (any, error)) (any, error) {
+	key = normalizeKey(key)
+	if key == "" {
+		return nil, errors.New("variable name is required")
+	}
+
+	c.mtx.Lock()
+	old, exists := c.values[key]
+	value, err := fn(old)
+	if err != nil {
+		c.mtx.Unlock()
+		return nil, err
+	}
+	if exists && fmt.Sprintf("%v", old) == fmt.Sprintf("%v", value) {
+		c.mtx.Unlock()
+		return value, nil
+	}
+	c.values[key] = value
+	if err := c.save(); err != nil {
+		// keep memory and disk in sync
+		if exists {
+			c.values[key] = old
+		} else {
+			delete(c.values, key)
+		}
+		c.mtx.Unlock()
+		return nil, err
+	}
+	changed, _ := c.fields.RefreshMatching(matchKey(key), c.lookup)
+	c.mtx.Unlock()
+
+	c.fields.Emit(changed)
+	return value, nil
+}
+
+func (c *client) Delete(key string) error {
+	key = normalizeKey(key)
+
+	c.mtx.Lock()
+	old, exists := c.values[key]
+	if !exists {
+		c.mtx.Unlock()
+		return fmt.Errorf("variable '%s' not found", key)
+	}
+	delete(c.values, key)
+	err := c.save()
+	if err != nil {
+		c.values[key] = old
+		c.mtx.Unlock()
+		return err
+	}
+	changed, _ := c.fields.RefreshMatching(matchKey(key), c.lookup)
+	c.mtx.Unlock()
+
+	c.fields.Emit(changed)
+	return nil
+}
+
+func (c *client) List() map[string]any {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+
+	return maps.Clone(c.values)
+}
+
+func (c *client) Close() {
+	c.logger.Info().Msg("variables client closed")
+}
+
+// save writes all variables to a temporary file and renames it over the variables file,
+// so a crash mid-write never leaves a truncated file behind. The caller must hold c.mtx.
+func (c *client) save() error {
+	content, err := json.MarshalIndent(c.values, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode variables: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(c.cfg.FilePath), filepath.Base(c.cfg.FilePath)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write variables file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write variables file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write variables file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), c.cfg.FilePath); err != nil {
+		return fmt.Errorf("failed to write variables file: %w", err)
+	}
+	return nil
+}
+
+// lookup returns the value of a variable, variables that are not set are empty. The caller must hold c.mtx.
+func (c *client) lookup(key string) (any, error) {
+	return c.values[key], nil
+}
+
+// normalizeKey trims the variable names given by the operator and by layouts alike
+func normalizeKey(key string) string {
+	return strings.TrimSpace(key)
+}
+
+// matchKey selects every subscribed variant of a variable
+func matchKey(key string) func(string) bool {
+	return func(k string) bool {
+		return k == key
+	}
+}
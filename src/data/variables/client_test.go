@@ -0,0 +1,51 @@
+package variables
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	d "github.com/overlayfox/caspaw-cg/src/data"
+	"github.com/overlayfox/caspaw-cg/src/events"
+	"github.com/overlayfox/caspaw-cg/src/types"
+)
+
+func TestClientKeys(t *testing.T) {
+	processor := events.NewProcessor(context.Background(), zerolog.Nop())
+	defer processor.Close()
+
+	cfg := d.VariablesDataSource{Name: "Variables", FilePath: filepath.Join(t.TempDir(), "variables.json")}
+	c, err := NewClient(zerolog.Nop(), cfg, processor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	if _, err := c.Subscribe([]types.Location{{Key: " host "}}); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := c.Get(types.Location{Key: "host"})
+	if err != nil || data.Value != nil {
+		t.Fatalf("Get() of an unset subscribed variable = %v, %v, want empty data", data, err)
+	}
+	if _, err := c.Get(types.Location{Key: "guest"}); err == nil {
+		t.Errorf("Get() of an unset unsubscribed variable succeeded")
+	}
+
+	if err := c.Set(" host", "Kim"); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := c.Get(types.Location{Key: "host "}); err != nil || data.Value != "Kim" {
+		t.Errorf("Get() = %v, %v, want Kim", data, err)
+	}
+
+	if err := c.Delete("host "); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if values := c.List(); len(values) != 0 {
+		t.Errorf("List() after Delete() = %v, want empty", values)
+	}
+}
@@ -0,0 +1,104 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/rs/zerolog"
+)
+
+// FileWatcher calls reload once the writes to a set of files settled. The files are watched through
+// their directory, as editors, exports and Excel replace files on save instead of writing them in place.
+type FileWatcher struct {
+	logger zerolog.Logger
+
+	watcher *fsnotify.Watcher
+	paths   []string
+
+	debounce time.Duration
+	retries  int
+	reload   func() error
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// WatchFiles starts watching paths, which must be absolute and share a directory. reload is called
+// debounce after the last write, a failing reload, e.g. of a file still being written, is retried
+// up to retries times with a growing delay before waiting for the next write.
+func WatchFiles(ctx context.Context, logger zerolog.Logger, paths []string, debounce time.Duration, retries int, reload func() error) (*FileWatcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file watcher: %w", err)
+	}
+	if err := watcher.Add(filepath.Dir(paths[0])); err != nil {
+		watcher.Close()
+		return nil, fmt.Errorf("failed to watch '%s': %w", paths[0], err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	w := &FileWatcher{
+		logger: logger,
+
+		watcher: watcher,
+		paths:   paths,
+
+		debounce: debounce,
+		retries:  retries,
+		reload:   reload,
+
+		ctx:    ctx,
+		cancel: cancel,
+	}
+	w.wg.Go(w.watch)
+	return w, nil
+}
+
+func (w *FileWatcher) Close() {
+	w.cancel()
+	w.watcher.Close()
+	w.wg.Wait()
+}
+
+func (w *FileWatcher) watch() {
+	var reload <-chan time.Time
+	attempt := 0
+	for {
+		select {
+		case <-w.ctx.Done():
+			return
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			// temporary save files and lock files such as Excel's "~$" files don't match the watched paths
+			if !slices.Contains(w.paths, filepath.Clean(event.Name)) || (event.Has(fsnotify.Chmod) && !event.Has(fsnotify.Write)) {
+				continue
+			}
+			attempt = 0
+			reload = time.After(w.debounce)
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			w.logger.Error().Err(err).Msg("file watcher failed")
+		case <-reload:
+			reload = nil
+			if err := w.reload(); err != nil {
+				attempt++
+				if attempt > w.retries {
+					w.logger.Error().Err(err).Msg("failed to reload file, waiting for the next write")
+					continue
+				}
+				w.logger.Debug().Err(err).Int("attempt", attempt).Msg("file not readable yet, retrying")
+				reload = time.After(time.Duration(attempt) * w.debounce)
+			}
+		}
+	}
+}
@@ -73,5 +73,6 @@ func (p *processor) Close() {
 	for _, ch := range p.channels {
 		close(ch)
 	}
+	p.channels = nil
 	p.mtx.Unlock()
 }
@@ -0,0 +1,52 @@
+package events
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	"github.com/overlayfox/caspaw-cg/src/types"
+)
+
+func TestProcessorAfterClose(t *testing.T) {
+	tests := []struct {
+		name string
+		call func(p types.EventProcessor, ch <-chan types.Event)
+	}{
+		{
+			name: "push",
+			call: func(p types.EventProcessor, _ <-chan types.Event) {
+				if err := p.Push(types.CasparCGKeepAlive{}); err != nil {
+					t.Errorf("Push() error = %v, want nil", err)
+				}
+			},
+		},
+		{
+			name: "close channel",
+			call: func(p types.EventProcessor, ch <-chan types.Event) {
+				p.CloseChannel(ch)
+			},
+		},
+		{
+			name: "close twice",
+			call: func(p types.EventProcessor, _ <-chan types.Event) {
+				p.Close()
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Push picks randomly between a closed listener and the cancelled context, repeat to hit both
+			for range 100 {
+				p := NewProcessor(context.Background(), zerolog.Nop())
+				ch := p.Listen()
+				p.Close()
+				if _, ok := <-ch; ok {
+					t.Fatal("listener channel still open after Close")
+				}
+				tt.call(p, ch)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package types
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Capability represents a server feature whose availability depends on the CasparCG version
+type Capability string
+
+const (
+	CapabilityBatching     Capability = "batching"      // BEGIN/COMMIT/DISCARD, 2.4+
+	CapabilityOSC          Capability = "osc"           // OSC output, 2.0.7+
+	CapabilityCGInfo       Capability = "cg-info"       // CG INFO returning template details, before 2.5
+	CapabilityMediaScanner Capability = "media-scanner" // CLS/TLS/CINF served by the external media scanner, 2.2+
+	CapabilityInfoChannels Capability = "info-channels" // INFO returning "<channel> <video-mode> <status>" lines, 2.1+
+	CapabilityHTML         Capability = "html"          // [HTML] producer and VERSION CEF, 2.1+
+	CapabilityFlash        Capability = "flash"         // flash templates and VERSION FLASH, before 2.3
+)
+
+// capabilityRequirement describes the version range [since, until) a capability is available in
+type capabilityRequirement struct {
+	since [3]int
+	until [3]int // zero value means no upper bound
+}
+
+var capabilityRequirements = map[Capability]capabilityRequirement{
+	CapabilityBatching:     {since: [3]int{2, 4, 0}},
+	CapabilityOSC:          {since: [3]int{2, 0, 7}},
+	CapabilityCGInfo:       {since: [3]int{2, 0, 0}, until: [3]int{2, 5, 0}},
+	CapabilityMediaScanner: {since: [3]int{2, 2, 0}},
+	CapabilityInfoChannels: {since: [3]int{2, 1, 0}},
+	CapabilityHTML:         {since: [3]int{2, 1, 0}},
+	CapabilityFlash:        {since: [3]int{2, 0, 0}, until: [3]int{2, 3, 0}},
+}
+
+// ServerCapabilities is the set of capabilities detected from the version a CasparCG server reports on connect
+type ServerCapabilities struct {
+	Version string `json:"version"`
+	// Detected is false if the version could not be queried or parsed,
+	// in which case every capability is assumed to be available.
+	Detected     bool                `json:"detected"`
+	Capabilities map[Capability]bool `json:"capabilities"`
+}
+
+// NewServerCapabilities builds the capability set from a VERSION response such as "2.5.0 4ac7e7fa Stable"
+func NewServerCapabilities(version string) (ServerCapabilities, error) {
+	version = strings.TrimSpace(version)
+	fields := strings.Fields(version)
+	if len(fields) == 0 {
+		return ServerCapabilities{Version: version}, fmt.Errorf("invalid server version: '%s'", version)
+	}
+
+	var parsed [3]int
+	parts := strings.SplitN(fields[0], ".", 4)
+	if len(parts) < 2 {
+		return ServerCapabilities{Version: version}, fmt.Errorf("invalid server version: '%s'", version)
+	}
+	for i := 0; i < len(parts) && i < 3; i++ {
+		n, err := strconv.Atoi(parts[i])
+		if err != nil {
+			return ServerCapabilities{Version: version}, fmt.Errorf("invalid server version: '%s'", version)
+		}
+		parsed[i] = n
+	}
+
+	capabilities := make(map[Capability]bool, len(capabilityRequirements))
+	for capability, req := range capabilityRequirements {
+		supported := slices.Compare(parsed[:], req.since[:]) >= 0
+		if req.until != [3]int{} && slices.Compare(parsed[:], req.until[:]) >= 0 {
+			supported = false
+		}
+		capabilities[capability] = supported
+	}
+
+	return ServerCapabilities{
+		Version:      version,
+		Detected:     true,
+		Capabilities: capabilities,
+	}, nil
+}
+
+// Has reports whether the capability is available, undetected servers are assumed to support everything
+func (s ServerCapabilities) Has(capability Capability) bool {
+	if !s.Detected {
+		return true
+	}
+	return s.Capabilities[capability]
+}
+
+// Require returns an error describing the missing capability if the server does not support it
+func (s ServerCapabilities) Require(capability Capability) error {
+	if s.Has(capability) {
+		return nil
+	}
+	return fmt.Errorf("CasparCG server %s does not support %s", s.Version, capability)
+}
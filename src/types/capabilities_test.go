@@ -0,0 +1,56 @@
+package types
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestNewServerCapabilities(t *testing.T) {
+	tests := []struct {
+		version string
+		want    map[Capability]bool
+		wantErr bool
+	}{
+		{version: "2.0.6 3412 Stable", want: map[Capability]bool{
+			CapabilityBatching: false, CapabilityOSC: false, CapabilityCGInfo: true, CapabilityMediaScanner: false,
+			CapabilityInfoChannels: false, CapabilityHTML: false, CapabilityFlash: true,
+		}},
+		{version: "2.0.7.e9fc25a Stable", want: map[Capability]bool{
+			CapabilityBatching: false, CapabilityOSC: true, CapabilityCGInfo: true, CapabilityMediaScanner: false,
+			CapabilityInfoChannels: false, CapabilityHTML: false, CapabilityFlash: true,
+		}},
+		{version: "2.3.3 LTS", want: map[Capability]bool{
+			CapabilityBatching: false, CapabilityOSC: true, CapabilityCGInfo: true, CapabilityMediaScanner: true,
+			CapabilityInfoChannels: true, CapabilityHTML: true, CapabilityFlash: false,
+		}},
+		{version: "2.4.0 4ac7e7fa Stable", want: map[Capability]bool{
+			CapabilityBatching: true, CapabilityOSC: true, CapabilityCGInfo: true, CapabilityMediaScanner: true,
+			CapabilityInfoChannels: true, CapabilityHTML: true, CapabilityFlash: false,
+		}},
+		{version: " 2.5.0 4ac7e7fa Stable\r\n", want: map[Capability]bool{
+			CapabilityBatching: true, CapabilityOSC: true, CapabilityCGInfo: false, CapabilityMediaScanner: true,
+			CapabilityInfoChannels: true, CapabilityHTML: true, CapabilityFlash: false,
+		}},
+		{version: "", wantErr: true},
+		{version: "2", wantErr: true},
+		{version: "two.five", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			got, err := NewServerCapabilities(tt.version)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewServerCapabilities(%q) error = %v, wantErr %v", tt.version, err, tt.wantErr)
+			}
+			if got.Detected == tt.wantErr || !maps.Equal(got.Capabilities, tt.want) {
+				t.Errorf("NewServerCapabilities(%q) = %v, %v, want %v", tt.version, got.Detected, got.Capabilities, tt.want)
+			}
+			// undetected servers are assumed to support everything
+			if tt.wantErr && (!got.Has(CapabilityBatching) || got.Require(CapabilityCGInfo) != nil) {
+				t.Errorf("undetected capabilities of %q are not assumed", tt.version)
+			}
+			if !tt.wantErr && tt.want[CapabilityCGInfo] != (got.Require(CapabilityCGInfo) == nil) {
+				t.Errorf("Require(%s) disagrees with Has for %q", CapabilityCGInfo, tt.version)
+			}
+		})
+	}
+}
@@ -47,11 +47,24 @@ func (r Resolution) AspectRatio() float64 {
 	return float64(r.Width) / float64(r.Height)
 }
 
+// DiscoveredCasparCGServer is an AMCP listener found while probing the network
+type DiscoveredCasparCGServer struct {
+	Host     string `json:"host"`
+	Hostname string `json:"hostname,omitempty"` // reverse DNS name of Host, if any
+	Port     int    `json:"port"`
+	Version  string `json:"version"`
+}
+
 type CasparCGClient interface {
 	Connect() error
+	// GetCapabilities returns the capabilities detected from the server version on the last (re)connect
+	GetCapabilities() ServerCapabilities
 	GetTemplates() ([]string, error)
 	GetMedia() ([]string, error)
 	GetMediaInfo(filename string) (responses.CINF, error)
+	GetDiagnostics() (ServerDiagnostics, error)
+	// GetTemplateInfo returns what CG INFO reports about the template playing on the layer, before CasparCG 2.5
+	GetTemplateInfo(channel, layer int) ([]string, error)
 
 	// Control functions for CG templates
 	AddCGData(template string, layer int, channels []int, data map[string]any, sizing Sizing, delay time.Duration) error
@@ -63,8 +76,21 @@ type CasparCGClient interface {
 	PlayMedia(filename string, layer int, channels []int, loop bool, delay time.Duration) error
 	StopMedia(layer int, channels []int, delay time.Duration) error
 
+	// Control functions for producers (route, colour, html), stopped the same way as media
+	PlayProducer(producer Producer, layer int, channels []int, delay time.Duration) error
+
 	ClearAll()
 	ClearChannels(channels []int)
 
 	Close()
 }
+
+// RedundantCasparCGClient is a CasparCGClient that mirrors every command to a primary and a backup server
+type RedundantCasparCGClient interface {
+	CasparCGClient
+
+	// GetActive returns the role of the server currently considered live
+	GetActive() CasparCGServerRole
+	// SetActive manually switches the live server
+	SetActive(role CasparCGServerRole) error
+}
@@ -0,0 +1,285 @@
+package types
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// spreadsheetEpoch is day 0 of spreadsheet date serial numbers, e.g. unformatted Google Sheets dates
+var spreadsheetEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// dateLayouts are tried in order, dates with slashes are read month first like US spreadsheets
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"2.1.2006 15:04:05",
+	"2.1.2006 15:04",
+	"2.1.2006",
+	"1/2/2006 15:04:05",
+	"1/2/2006 15:04",
+	"1/2/2006",
+	"2006/01/02",
+}
+
+// timeLayouts hold a time of day only, the date is today
+var timeLayouts = []string{"15:04:05", "15:04"}
+
+// ambiguousPattern matches numbers whose only separator could group thousands or separate decimals, e.g. "1,234"
+var ambiguousPattern = regexp.MustCompile(`^[-+]?[1-9]\d{0,2}[.,]\d{3}$`)
+
+var rgbPattern = regexp.MustCompile(`^rgba?\(\s*(\d+)\s*,\s*(\d+)\s*,\s*(\d+)\s*(?:,\s*([\d.]+)\s*)?\)$`)
+
+// Coercer converts the values datasources return into the DataType of the field they feed,
+// so templates receive e.g. the number 3.5 for the cell "3,5".
+type Coercer struct {
+	// DecimalSeparator is '.' or ',', zero guesses it per value: the last separator is the decimal
+	// separator if both appear ("1.234,5"), a single one is a decimal separator ("3,5") and a
+	// repeated one groups thousands ("1.234.567"). A single separator followed by exactly three
+	// digits ("1,234") could be either and is an error.
+	DecimalSeparator rune
+	// Location is the timezone of dates without one, nil uses the local timezone
+	Location *time.Location
+}
+
+// Coerce converts value to dataType. Empty values stay nil, an empty dataType keeps the value as is.
+//
+// Results are int64 for int, float64 for float, bool for bool, "2006-01-02" for date, RFC 3339 for
+// datetime, "#RRGGBB" or "#RRGGBBAA" for color and strings for string, path and url.
+func (c Coercer) Coerce(value any, dataType DataType) (any, error) {
+	if dataType == "" {
+		return value, nil
+	}
+	if b, ok := value.([]byte); ok {
+		value = string(b)
+	}
+	if s, ok := value.(string); ok && dataType != DataTypeString {
+		value = strings.TrimSpace(s)
+	}
+	if value == nil || (value == "" && dataType != DataTypeString) {
+		return nil, nil
+	}
+
+	switch dataType {
+	case DataTypeString:
+		if s, ok := value.(string); ok {
+			return s, nil
+		}
+		return fmt.Sprintf("%v", value), nil
+	case DataTypeInt:
+		number, err := c.number(value)
+		if err != nil {
+			return nil, err
+		}
+		if number != math.Trunc(number) || math.Abs(number) > 1<<53 {
+			return nil, fmt.Errorf("'%v' is not a whole number", value)
+		}
+		return int64(number), nil
+	case DataTypeFloat:
+		return orNil(c.number(value))
+	case DataTypeBool:
+		return orNil(toBool(value))
+	case DataTypeDate:
+		date, err := c.dateTime(value)
+		if err != nil {
+			return nil, err
+		}
+		return date.Format(time.DateOnly), nil
+	case DataTypeDateTime:
+		date, err := c.dateTime(value)
+		if err != nil {
+			return nil, err
+		}
+		return date.Format(time.RFC3339), nil
+	case DataTypeColor:
+		return orNil(toColor(value))
+	case DataTypePath:
+		path := fmt.Sprintf("%v", value)
+		if strings.ContainsFunc(path, unicode.IsControl) {
+			return nil, fmt.Errorf("path '%s' contains control characters", path)
+		}
+		return path, nil
+	case DataTypeURL:
+		raw := fmt.Sprintf("%v", value)
+		u, err := url.Parse(raw)
+		if err != nil || u.Scheme == "" || (u.Host == "" && u.Opaque == "" && u.Path == "") {
+			return nil, fmt.Errorf("'%s' is not an absolute url", raw)
+		}
+		return raw, nil
+	}
+	return nil, fmt.Errorf("unknown data type '%s'", dataType)
+}
+
+// orNil drops the zero value returned with an error, failed conversions are nil
+func orNil[T any](value T, err error) (any, error) {
+	if err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func (c Coercer) number(value any) (float64, error) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	case float32:
+		return float64(v), nil
+	case string:
+		return c.parseNumber(v)
+	}
+	return 0, fmt.Errorf("'%v' is not a number", value)
+}
+
+// parseNumber reads numbers as spreadsheets display them, e.g. "1.234,5", "1 234.5" or "50%"
+func (c Coercer) parseNumber(s string) (float64, error) {
+	raw := s
+	s = strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '\u00a0', '\u202f', '\'', '’': // thousands separators
+			return -1
+		}
+		return r
+	}, s)
+	percent := strings.HasSuffix(s, "%")
+	s = strings.TrimSuffix(s, "%")
+
+	decimal := c.DecimalSeparator
+	if decimal == 0 {
+		dot, comma := strings.LastIndex(s, "."), strings.LastIndex(s, ",")
+		switch {
+		case dot >= 0 && comma >= 0:
+			decimal = '.'
+			if comma > dot {
+				decimal = ','
+			}
+		case strings.Count(s, ".") > 1:
+			decimal = ','
+		case comma >= 0 && strings.Count(s, ",") == 1:
+			decimal = ','
+		default:
+			decimal = '.'
+		}
+		if ambiguousPattern.MatchString(s) {
+			return 0, fmt.Errorf("'%s' could be a decimal or a thousands separated number, set decimal_separator", raw)
+		}
+	}
+	group := ","
+	if decimal == ',' {
+		group = "."
+	}
+	s = strings.ReplaceAll(s, group, "")
+	s = strings.ReplaceAll(s, string(decimal), ".")
+
+	number, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("'%s' is not a number", raw)
+	}
+	if percent {
+		number /= 100
+	}
+	return number, nil
+}
+
+func toBool(value any) (bool, error) {
+	switch v := value.(type) {
+	case bool:
+		return v, nil
+	case int, int64, float64:
+		return fmt.Sprintf("%v", v) != "0", nil
+	case string:
+		switch strings.ToLower(v) {
+		case "true", "yes", "y", "on", "1", "x", "✓":
+			return true, nil
+		case "false", "no", "n", "off", "0", "-":
+			return false, nil
+		}
+	}
+	return false, fmt.Errorf("'%v' is not a boolean", value)
+}
+
+func (c Coercer) dateTime(value any) (time.Time, error) {
+	location := c.Location
+	if location == nil {
+		location = time.Local
+	}
+
+	switch v := value.(type) {
+	case time.Time:
+		return v, nil
+	case float64, int64, int:
+		days, _ := c.number(v)
+		serial := spreadsheetEpoch.Add(time.Duration(days * float64(24*time.Hour))).Round(time.Second)
+		// serial numbers count wall clock time, they carry no timezone
+		return time.Date(serial.Year(), serial.Month(), serial.Day(), serial.Hour(), serial.Minute(), serial.Second(), 0, location), nil
+	case string:
+		for _, layout := range dateLayouts {
+			if t, err := time.ParseInLocation(layout, v, location); err == nil {
+				return t, nil
+			}
+		}
+		for _, layout := range timeLayouts {
+			if t, err := time.ParseInLocation(layout, v, location); err == nil {
+				now := time.Now().In(location)
+				return time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), t.Second(), 0, location), nil
+			}
+		}
+	}
+	return time.Time{}, fmt.Errorf("'%v' is not a date", value)
+}
+
+// toColor normalizes "#abc", "ff0000", "rgb(255, 0, 0)" and "rgba(255, 0, 0, 0.5)" to upper-case hex
+func toColor(value any) (string, error) {
+	s, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("'%v' is not a colour", value)
+	}
+
+	if match := rgbPattern.FindStringSubmatch(strings.ToLower(s)); match != nil {
+		channels := make([]byte, 0, 4)
+		for _, channel := range match[1:4] {
+			n, err := strconv.Atoi(channel)
+			if err != nil || n > 255 {
+				return "", fmt.Errorf("'%s' is not a colour", s)
+			}
+			channels = append(channels, byte(n))
+		}
+		if match[4] != "" {
+			alpha, err := strconv.ParseFloat(match[4], 64)
+			if err != nil || alpha > 1 {
+				return "", fmt.Errorf("'%s' is not a colour", s)
+			}
+			channels = append(channels, byte(math.Round(alpha*255)))
+		}
+		return "#" + strings.ToUpper(hex.EncodeToString(channels)), nil
+	}
+
+	digits := strings.TrimPrefix(s, "#")
+	if len(digits) == 3 || len(digits) == 4 {
+		var expanded strings.Builder
+		for _, r := range digits {
+			expanded.WriteRune(r)
+			expanded.WriteRune(r)
+		}
+		digits = expanded.String()
+	}
+	if len(digits) != 6 && len(digits) != 8 {
+		return "", fmt.Errorf("'%s' is not a colour", s)
+	}
+	if _, err := hex.DecodeString(digits); err != nil {
+		return "", fmt.Errorf("'%s' is not a colour", s)
+	}
+	return "#" + strings.ToUpper(digits), nil
+}
@@ -0,0 +1,87 @@
+package types
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCoercerParseNumber(t *testing.T) {
+	tests := []struct {
+		raw     string
+		decimal rune
+		want    float64
+		wantErr bool
+	}{
+		{raw: "42", want: 42},
+		{raw: "-3.5", want: -3.5},
+		{raw: "3,5", want: 3.5},
+		{raw: "0,125", want: 0.125},
+		{raw: "1234,567", want: 1234.567},
+		{raw: "1.234,5", want: 1234.5},
+		{raw: "1,234.5", want: 1234.5},
+		{raw: "1,234,567", want: 1234567},
+		{raw: "1.234.567", want: 1234567},
+		{raw: "1 234.5", want: 1234.5},
+		{raw: "1 234,5", want: 1234.5},
+		{raw: "1'234.5", want: 1234.5},
+		{raw: "50%", want: 0.5},
+		{raw: "12,5%", want: 0.125},
+		{raw: "1,234", wantErr: true},
+		{raw: "-1.234", wantErr: true},
+		{raw: "1,234", decimal: ',', want: 1.234},
+		{raw: "1,234", decimal: '.', want: 1234},
+		{raw: "1.234", decimal: ',', want: 1234},
+		{raw: "1.234,5", decimal: ',', want: 1234.5},
+		{raw: "abc", wantErr: true},
+		{raw: "1,2,3.4,5", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			got, err := Coercer{DecimalSeparator: tt.decimal}.parseNumber(tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseNumber(%q) error = %v, wantErr %v", tt.raw, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseNumber(%q) = %v, want %v", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCoercerDateTime(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("no timezone database: %v", err)
+	}
+	now := time.Now().In(berlin)
+
+	tests := []struct {
+		name    string
+		value   any
+		want    time.Time
+		wantErr bool
+	}{
+		{name: "rfc3339", value: "2026-03-09T14:05:00Z", want: time.Date(2026, 3, 9, 14, 5, 0, 0, time.UTC)},
+		{name: "iso local", value: "2026-03-09 14:05", want: time.Date(2026, 3, 9, 14, 5, 0, 0, berlin)},
+		{name: "date only", value: "2026-03-09", want: time.Date(2026, 3, 9, 0, 0, 0, 0, berlin)},
+		{name: "dotted", value: "9.3.2026", want: time.Date(2026, 3, 9, 0, 0, 0, 0, berlin)},
+		{name: "slashes month first", value: "3/9/2026 14:05", want: time.Date(2026, 3, 9, 14, 5, 0, 0, berlin)},
+		{name: "time of day", value: "14:05", want: time.Date(now.Year(), now.Month(), now.Day(), 14, 5, 0, 0, berlin)},
+		{name: "serial", value: 46090.5, want: time.Date(2026, 3, 9, 12, 0, 0, 0, berlin)},
+		{name: "serial int", value: int64(1), want: time.Date(1899, 12, 31, 0, 0, 0, 0, berlin)},
+		{name: "time", value: time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC), want: time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC)},
+		{name: "not a date", value: "tomorrow", wantErr: true},
+		{name: "bool", value: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Coercer{Location: berlin}.dateTime(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("dateTime(%v) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("dateTime(%v) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 	"unicode"
 )
 
@@ -16,17 +17,50 @@ const (
 	DataTypeFloat  DataType = "float"
 	DataTypePath   DataType = "path"
 	DataTypeURL    DataType = "url"
+	DataTypeBool   DataType = "bool"
+	// DataTypeDate is a calendar day, DataTypeDateTime a point in time
+	DataTypeDate     DataType = "date"
+	DataTypeDateTime DataType = "datetime"
+	DataTypeColor    DataType = "color"
+)
+
+// ValueRender selects how a spreadsheet cell is read, datasources without formatting ignore it
+type ValueRender string
+
+const (
+	// ValueRenderFormatted reads the value as displayed, e.g. "50%" (default)
+	ValueRenderFormatted ValueRender = "FORMATTED_VALUE"
+	// ValueRenderUnformatted reads the underlying value, e.g. 0.5 for "50%"
+	ValueRenderUnformatted ValueRender = "UNFORMATTED_VALUE"
+	// ValueRenderFormula reads the formula of formula cells, e.g. "=B2/C2"
+	ValueRenderFormula ValueRender = "FORMULA"
 )
 
 // Location represents a location in a data source, identified by a key and its data type.
 type Location struct {
 	Key  string
 	Type DataType
+	// Render selects formatted, unformatted or formula values, empty reads formatted values
+	Render ValueRender
+	// Rich also fetches the cell's colours, hyperlink and note from datasources that support it
+	Rich bool
+}
+
+// Variant returns the location without its data type, locations of the same variant read the same value
+// from a datasource. Datasources convert nothing, the type only matters to the resolver.
+func (l Location) Variant() Location {
+	if l.Render == "" {
+		l.Render = ValueRenderFormatted
+	}
+	return Location{Key: l.Key, Render: l.Render, Rich: l.Rich}
 }
 
 // Range represents a range of data in a data source, identified by a key and its data type.
+// Locations hold the cells row by row, e.g. A2, B2, A3, B3 for "Sheet1!A2:B3".
 type Range struct {
 	Locations []Location
+	// Columns is the number of cells per row
+	Columns int
 }
 
 func NewRange(input string) (Range, error) {
@@ -50,20 +84,72 @@ func NewRange(input string) (Range, error) {
 		return Range{}, fmt.Errorf("invalid range format: %s", input)
 	}
 
-	if startCol != endCol {
-		return Range{}, fmt.Errorf("invalid range format: %s (range must span a single column)", input)
+	startColNum, endColNum := colToNum(startCol), colToNum(endCol)
+	if endColNum < startColNum {
+		return Range{}, fmt.Errorf("invalid range format: %s (end column before start column)", input)
 	}
 
 	if endRow < startRow {
 		return Range{}, fmt.Errorf("invalid range format: %s (end row before start row)", input)
 	}
 
-	locations := make([]Location, 0, endRow-startRow+1)
+	columns := endColNum - startColNum + 1
+	locations := make([]Location, 0, (endRow-startRow+1)*columns)
 	for row := startRow; row <= endRow; row++ {
-		locations = append(locations, Location{Key: sheetQualifiedKey(sheet, fmt.Sprintf("%s%d", startCol, row))})
+		for col := startColNum; col <= endColNum; col++ {
+			locations = append(locations, Location{Key: sheetQualifiedKey(sheet, fmt.Sprintf("%s%d", numToCol(col), row))})
+		}
+	}
+
+	return Range{Locations: locations, Columns: columns}, nil
+}
+
+// RowCount returns the number of rows in the range
+func (r Range) RowCount() int {
+	return len(r.Locations) / r.columns()
+}
+
+// Row returns the cells of the 0-based row i, or nil if i is outside the range
+func (r Range) Row(i int) []Location {
+	columns := r.columns()
+	if i < 0 || (i+1)*columns > len(r.Locations) {
+		return nil
+	}
+	return r.Locations[i*columns : (i+1)*columns]
+}
+
+// ColumnLetters returns the letters of the range's columns, e.g. ["A", "B"] for "Sheet1!A2:B20"
+func (r Range) ColumnLetters() []string {
+	letters := make([]string, 0, r.columns())
+	for _, loc := range r.Row(0) {
+		cell, err := ParseCell(loc.Key)
+		if err != nil {
+			return nil
+		}
+		letters = append(letters, numToCol(cell.Column))
 	}
+	return letters
+}
+
+// HeaderLocations returns the cells of the 1-based sheet row in the range's columns,
+// e.g. A1 and B1 for the header row 1 of "Sheet1!A2:B20".
+func (r Range) HeaderLocations(row int) []Location {
+	first := r.Row(0)
+	header := make([]Location, 0, len(first))
+	for _, loc := range first {
+		cell, err := ParseCell(loc.Key)
+		if err != nil {
+			return nil
+		}
+		cell.Row = row
+		header = append(header, Location{Key: cell.Key(), Type: DataTypeString})
+	}
+	return header
+}
 
-	return Range{Locations: locations}, nil
+// columns treats ranges built without NewRange as a single column
+func (r Range) columns() int {
+	return max(r.Columns, 1)
 }
 
 // splitSheetPrefix splits a "Sheet1!A1:A10"-style (optionally quoted, e.g. "'CG-System'!A1:A10")
@@ -124,30 +210,57 @@ func parseCellRef(cell string) (string, int, bool) {
 	return col, row, true
 }
 
-func (r Range) Key() string {
-	if len(r.Locations) == 0 {
-		return ""
+// Cell is a single cell address parsed from a location key such as "'Sheet1'!B7"
+type Cell struct {
+	Sheet  string
+	Column int // 1-based, A == 1
+	Row    int // 1-based
+}
+
+// ParseCell parses a sheet qualified single cell key such as "Sheet1!B7" or "'CG-System'!B7".
+func ParseCell(key string) (Cell, error) {
+	sheet, body, ok := splitSheetPrefix(key)
+	if !ok {
+		return Cell{}, fmt.Errorf("invalid location format '%s', use 'sheet1!A1'", key)
 	}
+	col, row, ok := parseCellRef(body)
+	if !ok {
+		return Cell{}, fmt.Errorf("invalid location format '%s', use 'sheet1!A1'", key)
+	}
+	return Cell{Sheet: sheet, Column: colToNum(col), Row: row}, nil
+}
 
-	colToNum := func(col string) int {
-		n := 0
-		for _, ch := range col {
-			n = n*26 + int(ch-'A'+1)
-		}
-		return n
+// Key returns the sheet qualified key of the cell, e.g. "'Sheet1'!B7"
+func (c Cell) Key() string {
+	return sheetQualifiedKey(c.Sheet, fmt.Sprintf("%s%d", numToCol(c.Column), c.Row))
+}
+
+// colToNum converts column letters such as "AB" into their 1-based column number
+func colToNum(col string) int {
+	n := 0
+	for _, ch := range col {
+		n = n*26 + int(ch-'A'+1)
 	}
+	return n
+}
 
-	numToCol := func(n int) string {
-		if n <= 0 {
-			return ""
-		}
-		out := ""
-		for n > 0 {
-			n--
-			out = string(rune('A'+(n%26))) + out
-			n /= 26
-		}
-		return out
+// numToCol converts a 1-based column number into its column letters
+func numToCol(n int) string {
+	if n <= 0 {
+		return ""
+	}
+	out := ""
+	for n > 0 {
+		n--
+		out = string(rune('A'+(n%26))) + out
+		n /= 26
+	}
+	return out
+}
+
+func (r Range) Key() string {
+	if len(r.Locations) == 0 {
+		return ""
 	}
 
 	minCol, maxCol := 0, 0
@@ -207,25 +320,124 @@ type Data struct {
 	Location
 
 	Value any
+	// Rich holds the cell's formatting for locations primed with Rich, nil otherwise
+	Rich *RichData
+}
+
+// RichData is the formatting of a spreadsheet cell, colours are "#RRGGBB" and empty if unset
+type RichData struct {
+	BackgroundColor string
+	TextColor       string
+	Hyperlink       string
+	Note            string
 }
 
 type DataSource interface {
 	// GetName returns the identifier of the Datasource
 	GetName() string
 
-	// Prime adds the specified locations to the data source for priming.
+	// Subscribe primes the specified locations and returns a handle to unsubscribe them again.
+	// Locations are reference counted, a location stays primed until every subscription holding it was removed.
 	// Location string example: "sheet1!A1" - currently only supports single fields
-	Prime(locations []Location) error
-	// RemovePrime removes the specified locations from the data source's primed data.
-	RemovePrime(keys []string) error
+	Subscribe(locations []Location) (string, error)
+	// Unsubscribe removes the locations of a subscription that no other subscription holds
+	Unsubscribe(handle string) error
 
-	// Get retrieves the data for the specified location.
-	Get(key string) (Data, error)
+	// Get retrieves the data for the specified location, locations of the same key that are rendered
+	// differently or rich are told apart
+	Get(location Location) (Data, error)
 
 	// Close closes the datasource
 	Close()
 }
 
+// StartableDataSource is a datasource reading values of other datasources. Start is called once every
+// configured datasource is registered, before that it serves no values.
+type StartableDataSource interface {
+	DataSource
+	Start() error
+}
+
+// SignInDataSource is a datasource the operator signs in to with their own account in the browser
+type SignInDataSource interface {
+	DataSource
+
+	// SignedIn reports whether the operator is signed in, the datasource serves no values before
+	SignedIn() bool
+	// SignIn opens the browser to sign in unless the operator is signed in or a sign-in is in progress,
+	// it returns without waiting for the operator
+	SignIn() error
+}
+
+// WritableDataSource is a datasource whose values can be changed from the UI
+type WritableDataSource interface {
+	DataSource
+
+	// Set writes value to the location key, primed keys reading it are updated immediately
+	Set(key string, value any) error
+	// Modify reads the current value of key straight from the datasource, subscribed or not, and writes
+	// the value fn returns for it. It returns the written value.
+	Modify(key string, fn func(current any) (any, error)) (any, error)
+}
+
+// VariablesDataSource is a datasource whose values are created and edited by the operator
+type VariablesDataSource interface {
+	WritableDataSource
+
+	// Delete removes a variable
+	Delete(key string) error
+	// List returns all variables
+	List() map[string]any
+}
+
+// TimingDataSource is a datasource of clocks, countdowns and stopwatches, the stopwatches are controlled by the operator
+type TimingDataSource interface {
+	DataSource
+
+	StartStopwatch(name string) error
+	StopStopwatch(name string) error
+	ResetStopwatch(name string) error
+	// SetStopwatch sets the elapsed time of a stopwatch, keeping it running if it was
+	SetStopwatch(name string, elapsed time.Duration) error
+}
+
+// PollingDataSource is a datasource that polls its source on an interval that can be changed at runtime
+type PollingDataSource interface {
+	DataSource
+
+	GetPollInterval() time.Duration
+	SetPollInterval(interval time.Duration) error
+	// SetOnAir marks the datasource as feeding graphics on air, which polls it faster.
+	// Calls are counted, every SetOnAir(true) has to be matched by a SetOnAir(false).
+	SetOnAir(onAir bool)
+}
+
+// SheetInfo describes a tab of a spreadsheet
+type SheetInfo struct {
+	Title   string
+	Rows    int
+	Columns int
+}
+
+// NamedRange is a range named in the spreadsheet, its name works as a location key
+type NamedRange struct {
+	Name  string
+	Range string // A1 notation, e.g. "'Sheet1'!B2"
+}
+
+// BrowsableDataSource is a datasource whose structure can be listed, so the UI can offer pickers
+// and check location keys before they go live
+type BrowsableDataSource interface {
+	DataSource
+
+	ListSheets() ([]SheetInfo, error)
+	// ListHeaders returns the values of the first row of a sheet
+	ListHeaders(sheet string) ([]string, error)
+	ListNamedRanges() ([]NamedRange, error)
+	// ValidateKey checks that a location key points into the spreadsheet
+	ValidateKey(key string) error
+}
+
 type DatasourceManager interface {
 	// AddDataSource adds a datasource
 	AddDataSource(ds DataSource) error
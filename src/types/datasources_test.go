@@ -0,0 +1,91 @@
+package types
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestNewRange(t *testing.T) {
+	tests := []struct {
+		input   string
+		key     string
+		rows    int
+		columns int
+		letters []string
+		wantErr bool
+	}{
+		{input: "Sheet1!A2:A5", key: "'Sheet1'!A2:A5", rows: 4, columns: 1, letters: []string{"A"}},
+		{input: "'CG-System'!A2:C20", key: "'CG-System'!A2:C20", rows: 19, columns: 3, letters: []string{"A", "B", "C"}},
+		{input: "Table!Y1:AB2", key: "'Table'!Y1:AB2", rows: 2, columns: 4, letters: []string{"Y", "Z", "AA", "AB"}},
+		{input: "Sheet1!b3:c4", key: "'Sheet1'!B3:C4", rows: 2, columns: 2, letters: []string{"B", "C"}},
+		{input: "Sheet1!C7:C7", key: "'Sheet1'!C7", rows: 1, columns: 1, letters: []string{"C"}},
+		{input: "A1:A10", wantErr: true},
+		{input: "Sheet1!A1", wantErr: true},
+		{input: "Sheet1!A1:B", wantErr: true},
+		{input: "Sheet1!B1:A10", wantErr: true},
+		{input: "Sheet1!A10:A1", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			r, err := NewRange(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewRange(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := r.Key(); got != tt.key {
+				t.Errorf("Key() = %q, want %q", got, tt.key)
+			}
+			if r.RowCount() != tt.rows || r.Columns != tt.columns || len(r.Locations) != tt.rows*tt.columns {
+				t.Errorf("got %d rows of %d columns and %d locations, want %d rows of %d columns", r.RowCount(), r.Columns, len(r.Locations), tt.rows, tt.columns)
+			}
+			if got := r.ColumnLetters(); !slices.Equal(got, tt.letters) {
+				t.Errorf("ColumnLetters() = %v, want %v", got, tt.letters)
+			}
+		})
+	}
+}
+
+func TestRangeRows(t *testing.T) {
+	r, err := NewRange("'Sheet 1'!B2:C4")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		row  int
+		want []string
+	}{
+		{row: 0, want: []string{"'Sheet 1'!B2", "'Sheet 1'!C2"}},
+		{row: 2, want: []string{"'Sheet 1'!B4", "'Sheet 1'!C4"}},
+		{row: 3, want: nil},
+		{row: -1, want: nil},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, loc := range r.Row(tt.row) {
+			got = append(got, loc.Key)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("Row(%d) = %v, want %v", tt.row, got, tt.want)
+		}
+	}
+
+	// a range rebuilt from its own key addresses the same cells
+	again, err := NewRange(r.Key())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(again.Locations, r.Locations) || again.Columns != r.Columns {
+		t.Errorf("NewRange(Key()) = %v, want %v", again, r)
+	}
+
+	var header []string
+	for _, loc := range r.HeaderLocations(1) {
+		header = append(header, loc.Key)
+	}
+	if want := []string{"'Sheet 1'!B1", "'Sheet 1'!C1"}; !slices.Equal(header, want) {
+		t.Errorf("HeaderLocations(1) = %v, want %v", header, want)
+	}
+}
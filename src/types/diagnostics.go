@@ -0,0 +1,34 @@
+package types
+
+import (
+	"time"
+
+	"github.com/overlayfox/casparcg-amcp-go/types/responses"
+)
+
+// ServerVersion holds the versions reported by the VERSION command for each server component
+type ServerVersion struct {
+	Server string `json:"server"`
+	Flash  string `json:"flash,omitempty"`
+	HTML   string `json:"html,omitempty"`
+}
+
+// ServerDiagnostics is a snapshot of what a CasparCG server reports about itself,
+// used to answer "which server and which paths" when a show breaks.
+type ServerDiagnostics struct {
+	Host        string    `json:"host"`
+	Port        int       `json:"port"`
+	CollectedAt time.Time `json:"collectedAt"`
+
+	Version  ServerVersion                `json:"version"`
+	System   []string                     `json:"system,omitempty"` // raw INFO SYSTEM lines, their format differs between server versions
+	Paths    responses.Paths              `json:"paths"`
+	Config   responses.CasparConfig       `json:"config"`
+	Channels []responses.QueryChannelInfo `json:"channels"`
+
+	Capabilities ServerCapabilities `json:"capabilities"`
+
+	// Errors maps a diagnostics section to the error the server returned for it.
+	// A failing section does not fail the whole collection.
+	Errors map[string]string `json:"errors,omitempty"`
+}
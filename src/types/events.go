@@ -8,7 +8,18 @@ type WailsPayload struct {
 type EventIdentifier string
 
 const (
-	EventIdentifierCasparCGKeepAlive EventIdentifier = "CasparCGKeepAlive"
+	EventIdentifierCasparCGKeepAlive    EventIdentifier = "CasparCGKeepAlive"
+	EventIdentifierCasparCGActiveServer EventIdentifier = "CasparCGActiveServer"
+	EventIdentifierCasparCGDivergence   EventIdentifier = "CasparCGDivergence"
+	EventIdentifierDataSourceStale      EventIdentifier = "DataSourceStale"
+)
+
+// CasparCGServerRole identifies a server in a main/backup pair
+type CasparCGServerRole string
+
+const (
+	CasparCGServerRolePrimary CasparCGServerRole = "primary"
+	CasparCGServerRoleBackup  CasparCGServerRole = "backup"
 )
 
 type CasparCGKeepAlive struct {
@@ -25,7 +36,54 @@ func (e CasparCGKeepAlive) GetData() any {
 	return e
 }
 
+// CasparCGActiveServer is pushed whenever the live server of a main/backup pair changes
+type CasparCGActiveServer struct {
+	Host string             `json:"host"`
+	Port int                `json:"port"`
+	Role CasparCGServerRole `json:"role"`
+}
+
+func (e CasparCGActiveServer) GetIdentifier() EventIdentifier {
+	return EventIdentifierCasparCGActiveServer
+}
+
+func (e CasparCGActiveServer) GetData() any {
+	return e
+}
+
+// CasparCGDivergence is pushed when a mirrored command succeeded on one server of a main/backup pair but failed on the other
+type CasparCGDivergence struct {
+	Command string             `json:"command"`
+	Host    string             `json:"host"`
+	Port    int                `json:"port"`
+	Role    CasparCGServerRole `json:"role"`
+	Error   string             `json:"error"`
+}
+
+func (e CasparCGDivergence) GetIdentifier() EventIdentifier {
+	return EventIdentifierCasparCGDivergence
+}
+
+func (e CasparCGDivergence) GetData() any {
+	return e
+}
+
+// DataSourceStale is pushed when a live datasource stops or resumes receiving data
+type DataSourceStale struct {
+	Name  string `json:"name"`
+	Stale bool   `json:"stale"`
+}
+
+func (e DataSourceStale) GetIdentifier() EventIdentifier {
+	return EventIdentifierDataSourceStale
+}
+
+func (e DataSourceStale) GetData() any {
+	return e
+}
+
 type DataSourceValueUpdate struct {
+	Source      string // name of the datasource, events of different datasources can share a key
 	LocationKey string
 	Value       any
 }
@@ -0,0 +1,120 @@
+package types
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ProducerKind represents the kind of CasparCG producer a producer widget plays out
+type ProducerKind string
+
+const (
+	ProducerKindRoute  ProducerKind = "route"
+	ProducerKindColour ProducerKind = "colour"
+	ProducerKindHTML   ProducerKind = "html"
+)
+
+// namedColours are the colour names the CasparCG colour producer understands besides hex values
+var namedColours = []string{"EMPTY", "BLACK", "WHITE", "RED", "GREEN", "BLUE", "ORANGE", "YELLOW", "BROWN", "GRAY", "TEAL"}
+
+// Producer represents a validated CasparCG producer that can be played out on a layer like a media file.
+type Producer struct {
+	Kind ProducerKind
+
+	// Clip is the producer string that takes the place of the clip name in a PLAY command
+	Clip string
+	// Parameters are additional arguments that follow the clip, e.g. the URL of an HTML producer
+	Parameters []string
+}
+
+// NewProducer validates source for the given kind and builds the matching producer.
+//
+// Source examples per kind:
+//   - route:  "1" or "1-10" (channel or channel-layer to mirror), "route://1-10" is accepted as well
+//   - colour: "#FF000000", "#FF0000" or a named colour such as "BLACK"
+//   - html:   "https://example.com/clock.html"
+func NewProducer(kind ProducerKind, source string) (Producer, error) {
+	source = strings.TrimSpace(source)
+	if source == "" {
+		return Producer{}, fmt.Errorf("invalid %s producer: source is required", kind)
+	}
+
+	switch kind {
+	case ProducerKindRoute:
+		return newRouteProducer(source)
+	case ProducerKindColour:
+		return newColourProducer(source)
+	case ProducerKindHTML:
+		return newHTMLProducer(source)
+	default:
+		return Producer{}, fmt.Errorf("unsupported producer kind: %s", kind)
+	}
+}
+
+func newRouteProducer(source string) (Producer, error) {
+	target := strings.TrimPrefix(strings.ToLower(source), "route://")
+
+	parts := strings.Split(target, "-")
+	if len(parts) > 2 {
+		return Producer{}, fmt.Errorf("invalid route producer: %s (expected e.g. '1' or '1-10')", source)
+	}
+	for _, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n <= 0 {
+			return Producer{}, fmt.Errorf("invalid route producer: %s (expected e.g. '1' or '1-10')", source)
+		}
+	}
+
+	return Producer{
+		Kind: ProducerKindRoute,
+		Clip: "route://" + target,
+	}, nil
+}
+
+func newColourProducer(source string) (Producer, error) {
+	upper := strings.ToUpper(source)
+	for _, name := range namedColours {
+		if upper == name {
+			return Producer{Kind: ProducerKindColour, Clip: name}, nil
+		}
+	}
+
+	hex, ok := strings.CutPrefix(upper, "#")
+	if !ok || (len(hex) != 6 && len(hex) != 8) {
+		return Producer{}, fmt.Errorf("invalid colour producer: %s (expected '#RRGGBB', '#AARRGGBB' or a named colour)", source)
+	}
+	if _, err := strconv.ParseUint(hex, 16, 32); err != nil {
+		return Producer{}, fmt.Errorf("invalid colour producer: %s (not a hex colour)", source)
+	}
+
+	return Producer{
+		Kind: ProducerKindColour,
+		Clip: "#" + hex,
+	}, nil
+}
+
+func newHTMLProducer(source string) (Producer, error) {
+	source = strings.TrimSpace(strings.TrimPrefix(source, "[HTML]"))
+
+	u, err := url.Parse(source)
+	if err != nil {
+		return Producer{}, fmt.Errorf("invalid html producer: %w", err)
+	}
+	switch u.Scheme {
+	case "http", "https":
+		if u.Host == "" {
+			return Producer{}, fmt.Errorf("invalid html producer: %s (missing host)", source)
+		}
+	case "file":
+	default:
+		return Producer{}, fmt.Errorf("invalid html producer: %s (scheme must be http, https or file)", source)
+	}
+
+	return Producer{
+		Kind:       ProducerKindHTML,
+		Clip:       "[HTML]",
+		Parameters: []string{u.String()},
+	}, nil
+}
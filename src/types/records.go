@@ -0,0 +1,131 @@
+package types
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// recordPattern matches the body of a record key, e.g. "Name[ID=17]"
+var recordPattern = regexp.MustCompile(`^([^\[\]]+)\[([^\[\]=]+)=([^\[\]]*)\]$`)
+
+// columnLetterPattern matches column letters used in place of a header name, e.g. "B"
+var columnLetterPattern = regexp.MustCompile(`^[A-Z]{1,3}$`)
+
+// RecordKey addresses a cell by content instead of position: the Column of the row whose KeyColumn
+// holds KeyValue, written as "'Sheet1'!Name[ID=17]". Column names are matched case-insensitively
+// against the first row of the sheet. Keys naming both columns by upper-case letters that no header
+// cell holds, such as "B[A=17]", skip the header lookup and search every row, for sheets without a
+// header. The row is looked up again on every fetch, so inserting or sorting rows doesn't change what
+// a key points at.
+type RecordKey struct {
+	Sheet     string
+	Column    string
+	KeyColumn string
+	KeyValue  string
+}
+
+// IsRecordKey reports whether key uses the record syntax rather than an A1 cell reference
+func IsRecordKey(key string) bool {
+	_, body, ok := splitSheetPrefix(key)
+	return ok && recordPattern.MatchString(body)
+}
+
+// ParseRecordKey parses a key such as "'Sheet1'!Name[ID=17]"
+func ParseRecordKey(key string) (RecordKey, error) {
+	sheet, body, ok := splitSheetPrefix(key)
+	if !ok {
+		return RecordKey{}, fmt.Errorf("invalid record location '%s', use 'Sheet1!Name[ID=17]'", key)
+	}
+	match := recordPattern.FindStringSubmatch(body)
+	if match == nil {
+		return RecordKey{}, fmt.Errorf("invalid record location '%s', use 'Sheet1!Name[ID=17]'", key)
+	}
+	return RecordKey{
+		Sheet:     sheet,
+		Column:    strings.TrimSpace(match[1]),
+		KeyColumn: strings.TrimSpace(match[2]),
+		KeyValue:  strings.TrimSpace(match[3]),
+	}, nil
+}
+
+// Key returns the sheet qualified key of the record, e.g. "'Sheet1'!Name[ID=17]"
+func (r RecordKey) Key() string {
+	return sheetQualifiedKey(r.Sheet, fmt.Sprintf("%s[%s=%s]", r.Column, r.KeyColumn, r.KeyValue))
+}
+
+// FindRecord resolves key against the rows of its sheet, the first row being the header unless key names
+// both columns by letters that are not in it.
+// A missing record resolves to nil like an empty cell, unknown columns are an error.
+func FindRecord[T any](key RecordKey, rows [][]T) (any, error) {
+	row, column, found, err := findRecord(key, rows)
+	if err != nil || !found || column >= len(rows[row]) {
+		return nil, err
+	}
+	return rows[row][column], nil
+}
+
+// FindRecordCell returns the cell key currently points at in rows, which have to start at A1 of the sheet.
+// found is false if no row holds the key value.
+func FindRecordCell[T any](key RecordKey, rows [][]T) (cell Cell, found bool, err error) {
+	row, column, found, err := findRecord(key, rows)
+	if err != nil || !found {
+		return Cell{}, false, err
+	}
+	return Cell{Sheet: key.Sheet, Column: column + 1, Row: row + 1}, true, nil
+}
+
+// findRecord returns the 0-based row and column indexes of the record
+func findRecord[T any](key RecordKey, rows [][]T) (row, column int, found bool, err error) {
+	var header []T
+	if len(rows) > 0 {
+		header = rows[0]
+	}
+	_, columnInHeader := headerIndex(header, key.Column)
+	_, keyColumnInHeader := headerIndex(header, key.KeyColumn)
+
+	var keyColumn int
+	first := 0 // the row the search starts at, past the header if the sheet has one
+	if columnLetterPattern.MatchString(key.Column) && columnLetterPattern.MatchString(key.KeyColumn) && !columnInHeader && !keyColumnInHeader {
+		column, keyColumn = colToNum(key.Column)-1, colToNum(key.KeyColumn)-1
+	} else {
+		column, err = columnIndex(header, key.Column)
+		if err != nil {
+			return 0, 0, false, fmt.Errorf("record location %s: %w", key.Key(), err)
+		}
+		keyColumn, err = columnIndex(header, key.KeyColumn)
+		if err != nil {
+			return 0, 0, false, fmt.Errorf("record location %s: %w", key.Key(), err)
+		}
+		first = 1
+	}
+
+	for i := first; i < len(rows); i++ {
+		if keyColumn < len(rows[i]) && strings.TrimSpace(fmt.Sprintf("%v", rows[i][keyColumn])) == key.KeyValue {
+			return i, column, true, nil
+		}
+	}
+	return 0, 0, false, nil
+}
+
+// columnIndex finds the 0-based index of a column by header name, falling back to column letters
+// for keys mixing a header name with a column letter
+func columnIndex[T any](header []T, name string) (int, error) {
+	if i, ok := headerIndex(header, name); ok {
+		return i, nil
+	}
+	if columnLetterPattern.MatchString(name) {
+		return colToNum(name) - 1, nil
+	}
+	return 0, fmt.Errorf("no column named '%s' in the header row", name)
+}
+
+// headerIndex finds the 0-based index of the header cell matching name case-insensitively
+func headerIndex[T any](header []T, name string) (int, bool) {
+	for i, cell := range header {
+		if strings.EqualFold(strings.TrimSpace(fmt.Sprintf("%v", cell)), name) {
+			return i, true
+		}
+	}
+	return 0, false
+}
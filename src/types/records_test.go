@@ -0,0 +1,91 @@
+package types
+
+import "testing"
+
+func TestParseRecordKey(t *testing.T) {
+	tests := []struct {
+		key     string
+		want    RecordKey
+		wantErr bool
+	}{
+		{key: "'Sheet1'!Name[ID=17]", want: RecordKey{Sheet: "Sheet1", Column: "Name", KeyColumn: "ID", KeyValue: "17"}},
+		{key: "Teams!Score[ Team = Home Side ]", want: RecordKey{Sheet: "Teams", Column: "Score", KeyColumn: "Team", KeyValue: "Home Side"}},
+		{key: "'Line-up 2'!B[A=]", want: RecordKey{Sheet: "Line-up 2", Column: "B", KeyColumn: "A", KeyValue: ""}},
+		{key: "Name[ID=17]", wantErr: true},
+		{key: "Sheet1!A1", wantErr: true},
+		{key: "Sheet1!Name[ID]", wantErr: true},
+		{key: "Sheet1!Name[ID=[17]]", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			got, err := ParseRecordKey(tt.key)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRecordKey(%q) error = %v, wantErr %v", tt.key, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseRecordKey(%q) = %+v, want %+v", tt.key, got, tt.want)
+			}
+			if !tt.wantErr && IsRecordKey(tt.key) != true {
+				t.Errorf("IsRecordKey(%q) = false, want true", tt.key)
+			}
+		})
+	}
+}
+
+func TestFindRecord(t *testing.T) {
+	withHeader := [][]any{
+		{"ID", "Name", "Score"},
+		{17, "Kim", 3},
+		{" 18 ", "Lee"},
+		{19, "Ali", 5},
+	}
+	noHeader := [][]any{
+		{17, "Kim", 3},
+		{18, "Lee", 4},
+	}
+
+	tests := []struct {
+		name     string
+		key      string
+		rows     [][]any
+		want     any
+		wantCell Cell
+		found    bool
+		wantErr  bool
+	}{
+		{name: "header names", key: "Sheet1!Name[ID=19]", rows: withHeader, want: "Ali", wantCell: Cell{Sheet: "Sheet1", Column: 2, Row: 4}, found: true},
+		{name: "case-insensitive header", key: "Sheet1!score[id=17]", rows: withHeader, want: 3, wantCell: Cell{Sheet: "Sheet1", Column: 3, Row: 2}, found: true},
+		{name: "trimmed key value", key: "Sheet1!Name[ID=18]", rows: withHeader, want: "Lee", wantCell: Cell{Sheet: "Sheet1", Column: 2, Row: 3}, found: true},
+		{name: "short row", key: "Sheet1!Score[ID=18]", rows: withHeader, want: nil, wantCell: Cell{Sheet: "Sheet1", Column: 3, Row: 3}, found: true},
+		{name: "header mixed with letter", key: "Sheet1!C[ID=19]", rows: withHeader, want: 5, wantCell: Cell{Sheet: "Sheet1", Column: 3, Row: 4}, found: true},
+		{name: "header row is not searched", key: "Sheet1!Name[ID=ID]", rows: withHeader, want: nil},
+		{name: "missing record", key: "Sheet1!Name[ID=20]", rows: withHeader, want: nil},
+		{name: "unknown column", key: "Sheet1!Points[ID=17]", rows: withHeader, wantErr: true},
+		{name: "letters search the first row", key: "Sheet1!B[A=17]", rows: noHeader, want: "Kim", wantCell: Cell{Sheet: "Sheet1", Column: 2, Row: 1}, found: true},
+		{name: "letters", key: "Sheet1!C[A=18]", rows: noHeader, want: 4, wantCell: Cell{Sheet: "Sheet1", Column: 3, Row: 2}, found: true},
+		{name: "letters past the row", key: "Sheet1!D[A=18]", rows: noHeader, want: nil, wantCell: Cell{Sheet: "Sheet1", Column: 4, Row: 2}, found: true},
+		{name: "empty sheet", key: "Sheet1!Name[ID=17]", rows: nil, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := ParseRecordKey(tt.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := FindRecord(key, tt.rows)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FindRecord() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("FindRecord() = %#v, want %#v", got, tt.want)
+			}
+			cell, found, err := FindRecordCell(key, tt.rows)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FindRecordCell() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if cell != tt.wantCell || found != tt.found {
+				t.Errorf("FindRecordCell() = %+v, %v, want %+v, %v", cell, found, tt.wantCell, tt.found)
+			}
+		})
+	}
+}
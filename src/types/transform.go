@@ -0,0 +1,286 @@
+package types
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxTransformDepth bounds how deep named transforms may reference each other, deeper chains are cycles
+const maxTransformDepth = 8
+
+type TransformKind string
+
+const (
+	// TransformNumber formats a number with Decimals, DecimalSeparator and ThousandsSeparator, e.g. "1.234,5"
+	TransformNumber TransformKind = "number"
+	// TransformOrdinal formats a whole number as an English ordinal, e.g. "2nd"
+	TransformOrdinal TransformKind = "ordinal"
+	// TransformPad left-pads to Length with Fill, "0" if empty, e.g. "07" for a shot clock
+	TransformPad TransformKind = "pad"
+	// TransformDate formats a date with the YYYY, YY, MM, M, DD, D, HH, H, mm and ss tokens of Format
+	TransformDate  TransformKind = "date"
+	TransformUpper TransformKind = "upper"
+	TransformLower TransformKind = "lower"
+	TransformTitle TransformKind = "title"
+	TransformTrim  TransformKind = "trim"
+	// TransformTruncate shortens to Length characters including Ellipsis, "…" if nil
+	TransformTruncate TransformKind = "truncate"
+	TransformPrefix   TransformKind = "prefix"
+	TransformSuffix   TransformKind = "suffix"
+	// TransformReplace replaces matches of the regular expression Pattern with Replacement, "$1" refers to groups
+	TransformReplace TransformKind = "replace"
+	// TransformLookup maps values through Table, unmatched values become Default or stay as they are if it is nil
+	TransformLookup TransformKind = "lookup"
+	// TransformNamed runs the transforms registered under Name
+	TransformNamed TransformKind = "named"
+)
+
+// Transform is one step of the formatting pipeline of a field, the fields used depend on Kind
+type Transform struct {
+	Kind TransformKind `json:"kind"`
+
+	Decimals           int    `json:"decimals,omitempty"`
+	DecimalSeparator   string `json:"decimalSeparator,omitempty"`
+	ThousandsSeparator string `json:"thousandsSeparator,omitempty"`
+
+	Format   string  `json:"format,omitempty"`
+	Length   int     `json:"length,omitempty"`
+	Fill     string  `json:"fill,omitempty"`
+	Ellipsis *string `json:"ellipsis,omitempty"`
+	Text     string  `json:"text,omitempty"`
+
+	Pattern     string `json:"pattern,omitempty"`
+	Replacement string `json:"replacement,omitempty"`
+
+	Table   map[string]string `json:"table,omitempty"`
+	Default *string           `json:"default,omitempty"`
+
+	Name string `json:"name,omitempty"`
+}
+
+// TransformSet holds transform chains by name, so widgets share them through TransformNamed steps
+type TransformSet map[string][]Transform
+
+// patterns caches compiled replace patterns, pipelines run on every update of a field
+var patterns sync.Map // map[pattern]*regexp.Regexp
+
+var dateTokenPattern = regexp.MustCompile(`YYYY|YY|MM|M|DD|D|HH|H|mm|ss`)
+
+// Apply runs steps on value in order. Empty values stay nil, so prefixes and suffixes don't show without a value.
+// Number, ordinal and date steps read text with coercer, like the resolver reads field values.
+func (s TransformSet) Apply(value any, steps []Transform, coercer Coercer) (any, error) {
+	return s.apply(value, steps, coercer, 0)
+}
+
+func (s TransformSet) apply(value any, steps []Transform, coercer Coercer, depth int) (any, error) {
+	if depth > maxTransformDepth {
+		return nil, fmt.Errorf("named transforms nested deeper than %d, check them for cycles", maxTransformDepth)
+	}
+	for _, step := range steps {
+		if value == nil {
+			return nil, nil
+		}
+		var err error
+		if step.Kind == TransformNamed {
+			named, ok := s[step.Name]
+			if !ok {
+				return nil, fmt.Errorf("no transform named '%s'", step.Name)
+			}
+			if value, err = s.apply(value, named, coercer, depth+1); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if value, err = step.apply(value, coercer); err != nil {
+			return nil, fmt.Errorf("%s transform: %w", step.Kind, err)
+		}
+	}
+	return value, nil
+}
+
+func (t Transform) apply(value any, coercer Coercer) (any, error) {
+	text := fmt.Sprintf("%v", value)
+	switch t.Kind {
+	case TransformNumber:
+		number, err := coercer.number(value)
+		if err != nil {
+			return nil, err
+		}
+		return formatNumber(number, t.Decimals, t.DecimalSeparator, t.ThousandsSeparator), nil
+	case TransformOrdinal:
+		number, err := coercer.number(value)
+		if err != nil {
+			return nil, err
+		}
+		if number != float64(int64(number)) {
+			return nil, fmt.Errorf("'%v' is not a whole number", value)
+		}
+		return ordinal(int64(number)), nil
+	case TransformPad:
+		fill := t.Fill
+		if fill == "" {
+			fill = "0"
+		}
+		if missing := t.Length - utf8.RuneCountInString(text); missing > 0 {
+			text = strings.Repeat(fill, missing) + text
+		}
+		return text, nil
+	case TransformDate:
+		date, err := coercer.dateTime(value)
+		if err != nil {
+			return nil, err
+		}
+		return formatDate(date, t.Format), nil
+	case TransformUpper:
+		return strings.ToUpper(text), nil
+	case TransformLower:
+		return strings.ToLower(text), nil
+	case TransformTitle:
+		return titleCase(text), nil
+	case TransformTrim:
+		return strings.TrimSpace(text), nil
+	case TransformTruncate:
+		ellipsis := "…"
+		if t.Ellipsis != nil {
+			ellipsis = *t.Ellipsis
+		}
+		runes := []rune(text)
+		if len(runes) <= t.Length {
+			return text, nil
+		}
+		keep := max(t.Length-utf8.RuneCountInString(ellipsis), 0)
+		return strings.TrimRightFunc(string(runes[:keep]), unicode.IsSpace) + ellipsis, nil
+	case TransformPrefix:
+		return t.Text + text, nil
+	case TransformSuffix:
+		return text + t.Text, nil
+	case TransformReplace:
+		pattern, err := compilePattern(t.Pattern)
+		if err != nil {
+			return nil, err
+		}
+		return pattern.ReplaceAllString(text, t.Replacement), nil
+	case TransformLookup:
+		if mapped, ok := t.Table[text]; ok {
+			return mapped, nil
+		}
+		if t.Default != nil {
+			return *t.Default, nil
+		}
+		return value, nil
+	}
+	return nil, fmt.Errorf("unknown transform '%s'", t.Kind)
+}
+
+func compilePattern(expr string) (*regexp.Regexp, error) {
+	if cached, ok := patterns.Load(expr); ok {
+		return cached.(*regexp.Regexp), nil
+	}
+	pattern, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern '%s': %w", expr, err)
+	}
+	patterns.Store(expr, pattern)
+	return pattern, nil
+}
+
+// formatNumber rounds to decimals and groups the integer digits in threes
+func formatNumber(number float64, decimals int, decimalSeparator, thousandsSeparator string) string {
+	if decimalSeparator == "" {
+		decimalSeparator = "."
+	}
+	sign := ""
+	if number < 0 {
+		sign = "-"
+		number = -number
+	}
+
+	// round half away from zero like spreadsheets, FormatFloat rounds 0.25 to 0.2. Rounding the shortest
+	// decimal representation rounds 1.005 to 1.01, although it is stored as 1.00499…
+	decimals = max(decimals, 0)
+	digits := strconv.FormatFloat(number, 'f', decimals, 64)
+	if exact, ok := new(big.Rat).SetString(strconv.FormatFloat(number, 'f', -1, 64)); ok {
+		digits = exact.FloatString(decimals)
+	}
+	if strings.Trim(digits, "0.") == "" {
+		sign = "" // no "-0" for values rounded to zero
+	}
+	integer, fraction, _ := strings.Cut(digits, ".")
+	if thousandsSeparator != "" {
+		var grouped strings.Builder
+		for i, digit := range integer {
+			if i > 0 && (len(integer)-i)%3 == 0 {
+				grouped.WriteString(thousandsSeparator)
+			}
+			grouped.WriteRune(digit)
+		}
+		integer = grouped.String()
+	}
+	if fraction != "" {
+		return sign + integer + decimalSeparator + fraction
+	}
+	return sign + integer
+}
+
+func ordinal(n int64) string {
+	suffix := "th"
+	switch abs := max(n, -n); {
+	case abs%100 >= 11 && abs%100 <= 13:
+	case abs%10 == 1:
+		suffix = "st"
+	case abs%10 == 2:
+		suffix = "nd"
+	case abs%10 == 3:
+		suffix = "rd"
+	}
+	return strconv.FormatInt(n, 10) + suffix
+}
+
+func formatDate(date time.Time, format string) string {
+	return dateTokenPattern.ReplaceAllStringFunc(format, func(token string) string {
+		switch token {
+		case "YYYY":
+			return fmt.Sprintf("%04d", date.Year())
+		case "YY":
+			return fmt.Sprintf("%02d", date.Year()%100)
+		case "MM":
+			return fmt.Sprintf("%02d", date.Month())
+		case "M":
+			return strconv.Itoa(int(date.Month()))
+		case "DD":
+			return fmt.Sprintf("%02d", date.Day())
+		case "D":
+			return strconv.Itoa(date.Day())
+		case "HH":
+			return fmt.Sprintf("%02d", date.Hour())
+		case "H":
+			return strconv.Itoa(date.Hour())
+		case "mm":
+			return fmt.Sprintf("%02d", date.Minute())
+		default: // ss
+			return fmt.Sprintf("%02d", date.Second())
+		}
+	})
+}
+
+// titleCase upper-cases the first letter of every word and lower-cases the rest, e.g. "o'neil-SMITH" to "O'Neil-Smith"
+func titleCase(text string) string {
+	var out strings.Builder
+	startOfWord := true
+	for _, r := range text {
+		if startOfWord {
+			out.WriteRune(unicode.ToUpper(r))
+		} else {
+			out.WriteRune(unicode.ToLower(r))
+		}
+		startOfWord = !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}
+	return out.String()
+}
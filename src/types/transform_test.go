@@ -0,0 +1,94 @@
+package types
+
+import "testing"
+
+func TestFormatNumber(t *testing.T) {
+	tests := []struct {
+		number             float64
+		decimals           int
+		decimalSeparator   string
+		thousandsSeparator string
+		want               string
+	}{
+		{number: 0.25, decimals: 1, want: "0.3"},
+		{number: 0.35, decimals: 1, want: "0.4"},
+		{number: 1.005, decimals: 2, want: "1.01"},
+		{number: 2.675, decimals: 2, want: "2.68"},
+		{number: -2.5, decimals: 0, want: "-3"},
+		{number: -0.004, decimals: 2, want: "0.00"},
+		{number: 3, decimals: 2, want: "3.00"},
+		{number: 12.5, decimals: -1, want: "13"},
+		{number: 1234567.891, decimals: 2, thousandsSeparator: ",", want: "1,234,567.89"},
+		{number: 1234.5, decimals: 1, decimalSeparator: ",", thousandsSeparator: ".", want: "1.234,5"},
+		{number: -999.5, decimals: 0, thousandsSeparator: " ", want: "-1 000"},
+		{number: 123, decimals: 0, thousandsSeparator: ",", want: "123"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := formatNumber(tt.number, tt.decimals, tt.decimalSeparator, tt.thousandsSeparator); got != tt.want {
+				t.Errorf("formatNumber(%v, %d) = %q, want %q", tt.number, tt.decimals, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTransformSetApply(t *testing.T) {
+	empty := ""
+	set := TransformSet{
+		"euro": {
+			{Kind: TransformNumber, Decimals: 2, DecimalSeparator: ",", ThousandsSeparator: "."},
+			{Kind: TransformSuffix, Text: " €"},
+		},
+		"loop": {{Kind: TransformNamed, Name: "loop"}},
+	}
+
+	tests := []struct {
+		name    string
+		value   any
+		steps   []Transform
+		coercer Coercer
+		want    any
+		wantErr bool
+	}{
+		{name: "no steps", value: 3.5, want: 3.5},
+		{name: "nil stays nil", value: nil, steps: []Transform{{Kind: TransformPrefix, Text: "#"}}, want: nil},
+		{name: "named", value: 1234.5, steps: []Transform{{Kind: TransformNamed, Name: "euro"}}, want: "1.234,50 €"},
+		{name: "number from text", value: "3,5", steps: []Transform{{Kind: TransformNumber, Decimals: 1}}, want: "3.5"},
+		{name: "ambiguous number", value: "1,234", steps: []Transform{{Kind: TransformNumber}}, wantErr: true},
+		{name: "number with decimal comma", value: "1,234", steps: []Transform{{Kind: TransformNumber, Decimals: 3}}, coercer: Coercer{DecimalSeparator: ','}, want: "1.234"},
+		{name: "number with decimal point", value: "1,234", steps: []Transform{{Kind: TransformNumber, ThousandsSeparator: " "}}, coercer: Coercer{DecimalSeparator: '.'}, want: "1 234"},
+		{name: "named with decimal point", value: "1,234.5", steps: []Transform{{Kind: TransformNamed, Name: "euro"}}, coercer: Coercer{DecimalSeparator: '.'}, want: "1.234,50 €"},
+		{name: "ordinal with decimal point", value: "1,001", steps: []Transform{{Kind: TransformOrdinal}}, coercer: Coercer{DecimalSeparator: '.'}, want: "1001st"},
+		{name: "ordinal", value: 22, steps: []Transform{{Kind: TransformOrdinal}}, want: "22nd"},
+		{name: "ordinal teen", value: int64(112), steps: []Transform{{Kind: TransformOrdinal}}, want: "112th"},
+		{name: "ordinal fraction", value: 2.5, steps: []Transform{{Kind: TransformOrdinal}}, wantErr: true},
+		{name: "pad", value: 7, steps: []Transform{{Kind: TransformPad, Length: 2}}, want: "07"},
+		{name: "pad fill", value: "ab", steps: []Transform{{Kind: TransformPad, Length: 4, Fill: "-"}}, want: "--ab"},
+		{name: "date", value: "2026-03-09 14:05", steps: []Transform{{Kind: TransformDate, Format: "D.M.YY HH:mm"}}, want: "9.3.26 14:05"},
+		{name: "upper then truncate", value: "kowalski", steps: []Transform{{Kind: TransformUpper}, {Kind: TransformTruncate, Length: 5}}, want: "KOWA…"},
+		{name: "truncate without ellipsis", value: "kowalski", steps: []Transform{{Kind: TransformTruncate, Length: 3, Ellipsis: &empty}}, want: "kow"},
+		{name: "truncate short", value: "kim", steps: []Transform{{Kind: TransformTruncate, Length: 5}}, want: "kim"},
+		{name: "title", value: "o'neil-SMITH", steps: []Transform{{Kind: TransformTitle}}, want: "O'Neil-Smith"},
+		{name: "trim prefix", value: "  7 ", steps: []Transform{{Kind: TransformTrim}, {Kind: TransformPrefix, Text: "#"}}, want: "#7"},
+		{name: "replace", value: "Smith, John", steps: []Transform{{Kind: TransformReplace, Pattern: `(\w+), (\w+)`, Replacement: "$2 $1"}}, want: "John Smith"},
+		{name: "replace invalid", value: "x", steps: []Transform{{Kind: TransformReplace, Pattern: "("}}, wantErr: true},
+		{name: "lookup", value: "GER", steps: []Transform{{Kind: TransformLookup, Table: map[string]string{"GER": "Germany"}}}, want: "Germany"},
+		{name: "lookup unmatched", value: "FRA", steps: []Transform{{Kind: TransformLookup, Table: map[string]string{"GER": "Germany"}}}, want: "FRA"},
+		{name: "lookup default", value: "FRA", steps: []Transform{{Kind: TransformLookup, Table: map[string]string{}, Default: &empty}}, want: ""},
+		{name: "not a number", value: "abc", steps: []Transform{{Kind: TransformNumber}}, wantErr: true},
+		{name: "unknown name", value: 1, steps: []Transform{{Kind: TransformNamed, Name: "missing"}}, wantErr: true},
+		{name: "cycle", value: 1, steps: []Transform{{Kind: TransformNamed, Name: "loop"}}, wantErr: true},
+		{name: "unknown kind", value: 1, steps: []Transform{{Kind: "shout"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := set.Apply(tt.value, tt.steps, tt.coercer)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Apply() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("Apply() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
@@ -10,7 +10,17 @@ import (
 	casparcg "github.com/overlayfox/caspaw-cg/src/caspar"
 	"github.com/overlayfox/caspaw-cg/src/config"
 	"github.com/overlayfox/caspaw-cg/src/data"
+	"github.com/overlayfox/caspaw-cg/src/data/csv"
+	"github.com/overlayfox/caspaw-cg/src/data/excel"
+	"github.com/overlayfox/caspaw-cg/src/data/feed"
 	"github.com/overlayfox/caspaw-cg/src/data/google/sheets"
+	"github.com/overlayfox/caspaw-cg/src/data/httpjson"
+	"github.com/overlayfox/caspaw-cg/src/data/mqtt"
+	"github.com/overlayfox/caspaw-cg/src/data/osc"
+	"github.com/overlayfox/caspaw-cg/src/data/push"
+	"github.com/overlayfox/caspaw-cg/src/data/sqldb"
+	"github.com/overlayfox/caspaw-cg/src/data/timing"
+	variablesDS "github.com/overlayfox/caspaw-cg/src/data/variables"
 	"github.com/overlayfox/caspaw-cg/src/events"
 	"github.com/overlayfox/caspaw-cg/src/types"
 )
@@ -18,6 +28,7 @@ import (
 // App struct
 type App struct {
 	logger zerolog.Logger
+	cfg    *config.Config
 
 	UIService         *UIService
 	dataSourceManager types.DatasourceManager
@@ -38,6 +49,20 @@ func NewApp(upstreamCtx context.Context, logger zerolog.Logger, config *config.C
 	eventsProcessor := events.NewProcessor(ctx, logger)
 
 	datasourceManager := data.NewManager(config.DataSourceManager)
+	variablesCfg := &data.VariablesDataSource{}
+	if config.DataSourceManager != nil && config.DataSourceManager.VariablesDataSource != nil {
+		variablesCfg = config.DataSourceManager.VariablesDataSource
+	}
+	if err := variablesCfg.Validate(); err != nil { // fills in the defaults if the section is omitted
+		cancel()
+		return nil, err
+	}
+	variables, err := variablesDS.NewClient(logger, *variablesCfg, eventsProcessor)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	datasourceManager.AddDataSource(variables)
 	if config.DataSourceManager != nil && config.DataSourceManager.GoogleSheetDataSource != nil {
 		for _, dataSource := range config.DataSourceManager.GoogleSheetDataSource {
 			client, err := sheets.NewClient(ctx, logger, dataSource, eventsProcessor)
@@ -48,17 +73,134 @@ func NewApp(upstreamCtx context.Context, logger zerolog.Logger, config *config.C
 			datasourceManager.AddDataSource(client)
 		}
 	}
+	if config.DataSourceManager != nil && config.DataSourceManager.CSVDataSource != nil {
+		for _, dataSource := range config.DataSourceManager.CSVDataSource {
+			client, err := csv.NewClient(ctx, logger, dataSource, eventsProcessor)
+			if err != nil {
+				cancel()
+				return nil, err
+			}
+			datasourceManager.AddDataSource(client)
+		}
+	}
+	if config.DataSourceManager != nil && config.DataSourceManager.XLSXDataSource != nil {
+		for _, dataSource := range config.DataSourceManager.XLSXDataSource {
+			client, err := excel.NewClient(ctx, logger, dataSource, eventsProcessor)
+			if err != nil {
+				cancel()
+				return nil, err
+			}
+			datasourceManager.AddDataSource(client)
+		}
+	}
+	if config.DataSourceManager != nil && config.DataSourceManager.HTTPDataSource != nil {
+		for _, dataSource := range config.DataSourceManager.HTTPDataSource {
+			client, err := httpjson.NewClient(ctx, logger, dataSource, eventsProcessor)
+			if err != nil {
+				cancel()
+				return nil, err
+			}
+			datasourceManager.AddDataSource(client)
+		}
+	}
+	if config.DataSourceManager != nil && config.DataSourceManager.PushDataSource != nil {
+		for _, dataSource := range config.DataSourceManager.PushDataSource {
+			client, err := push.NewClient(ctx, logger, dataSource, eventsProcessor)
+			if err != nil {
+				cancel()
+				return nil, err
+			}
+			datasourceManager.AddDataSource(client)
+		}
+	}
 
-	casparClient := casparcg.NewClient(ctx, logger, config.CasparCGClient, eventsProcessor)
-	err := casparClient.Connect()
+	if config.DataSourceManager != nil && config.DataSourceManager.SQLDataSource != nil {
+		for _, dataSource := range config.DataSourceManager.SQLDataSource {
+			client, err := sqldb.NewClient(ctx, logger, dataSource, eventsProcessor, datasourceManager)
+			if err != nil {
+				cancel()
+				return nil, err
+			}
+			datasourceManager.AddDataSource(client)
+		}
+	}
+
+	if config.DataSourceManager != nil && config.DataSourceManager.TimingDataSource != nil {
+		for _, dataSource := range config.DataSourceManager.TimingDataSource {
+			client, err := timing.NewClient(ctx, logger, dataSource, eventsProcessor)
+			if err != nil {
+				cancel()
+				return nil, err
+			}
+			datasourceManager.AddDataSource(client)
+		}
+	}
+
+	if config.DataSourceManager != nil && config.DataSourceManager.FeedDataSource != nil {
+		for _, dataSource := range config.DataSourceManager.FeedDataSource {
+			client, err := feed.NewClient(ctx, logger, dataSource, eventsProcessor)
+			if err != nil {
+				cancel()
+				return nil, err
+			}
+			datasourceManager.AddDataSource(client)
+		}
+	}
+
+	if config.DataSourceManager != nil && config.DataSourceManager.MQTTDataSource != nil {
+		for _, dataSource := range config.DataSourceManager.MQTTDataSource {
+			client, err := mqtt.NewClient(ctx, logger, dataSource, eventsProcessor)
+			if err != nil {
+				cancel()
+				return nil, err
+			}
+			datasourceManager.AddDataSource(client)
+		}
+	}
+
+	if config.DataSourceManager != nil && config.DataSourceManager.OSCDataSource != nil {
+		for _, dataSource := range config.DataSourceManager.OSCDataSource {
+			client, err := osc.NewClient(ctx, logger, dataSource, eventsProcessor)
+			if err != nil {
+				cancel()
+				return nil, err
+			}
+			datasourceManager.AddDataSource(client)
+		}
+	}
+
+	// datasources reading other datasources start once all of them are registered
+	for _, name := range datasourceManager.GetDataSourceNames() {
+		ds, _ := datasourceManager.GetDataSource(name)
+		if startable, ok := ds.(types.StartableDataSource); ok {
+			if err := startable.Start(); err != nil {
+				cancel()
+				return nil, err
+			}
+		}
+	}
+
+	var casparClient types.CasparCGClient
+	if config.CasparCGClient.Backup != nil {
+		casparClient = casparcg.NewRedundantClient(ctx, logger, config.CasparCGClient, eventsProcessor)
+	} else {
+		casparClient = casparcg.NewClient(ctx, logger, config.CasparCGClient, eventsProcessor)
+	}
+	err = casparClient.Connect()
 	if err != nil {
 		logger.Warn().Err(err).Msg("Failed to connect to CasparCG server")
 	} else {
 		logger.Debug().Str("host", config.CasparCGClient.Host).Int("port", config.CasparCGClient.Port).Msg("Connected to CasparCG server")
+		if config.DataSourceManager != nil && len(config.DataSourceManager.OSCDataSource) > 0 {
+			if err := casparClient.GetCapabilities().Require(types.CapabilityOSC); err != nil {
+				logger.Warn().Err(err).Msg("OSC datasources will not receive layer state from CasparCG")
+			}
+		}
 	}
 
 	a := &App{
 		logger: logger.With().Str("component", "app").Logger(),
+		cfg:    config,
 
 		eventProcessor:    eventsProcessor,
 		dataSourceManager: datasourceManager,
@@ -67,7 +209,11 @@ func NewApp(upstreamCtx context.Context, logger zerolog.Logger, config *config.C
 		ctx:    ctx,
 		cancel: cancel,
 	}
-	a.UIService = NewUIService(ctx, a, datasourceManager, casparClient)
+	var coercer types.Coercer
+	if config.DataSourceManager != nil {
+		coercer = config.DataSourceManager.Coercer()
+	}
+	a.UIService = NewUIService(ctx, a, datasourceManager, variables, coercer, casparClient)
 
 	return a, nil
 }
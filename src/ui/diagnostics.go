@@ -0,0 +1,99 @@
+package ui
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+
+	"github.com/overlayfox/caspaw-cg/src/types"
+)
+
+// GetCasparCGDiagnostics collects version, system, path, config and channel information from the CasparCG server.
+func (u *UIService) GetCasparCGDiagnostics() (types.ServerDiagnostics, error) {
+	diag, err := u.casparCGClient.GetDiagnostics()
+	if err != nil {
+		u.app.logger.Error().Err(err).Msg("Failed to get diagnostics from CasparCG client")
+		return types.ServerDiagnostics{}, err
+	}
+	return diag, nil
+}
+
+// ExportSupportBundle asks the user for a location and writes a zip file containing the server diagnostics,
+// the application config with its secrets redacted and the current layout to it.
+//
+// It returns the path of the written bundle, or an empty string if the user cancelled the dialog.
+func (u *UIService) ExportSupportBundle() (string, error) {
+	path, err := runtime.SaveFileDialog(u.app.wailsCtx, runtime.SaveDialogOptions{
+		Title:           "Export support bundle",
+		DefaultFilename: fmt.Sprintf("caspaw-cg-support-%s.zip", time.Now().Format("20060102-150405")),
+		Filters: []runtime.FileFilter{
+			{DisplayName: "Zip archives (*.zip)", Pattern: "*.zip"},
+		},
+	})
+	if err != nil {
+		u.app.logger.Error().Err(err).Msg("Failed to open save dialog for support bundle")
+		return "", err
+	}
+	if path == "" {
+		return "", nil
+	}
+
+	u.app.logger.Info().Msgf("Exporting support bundle to '%s'", path)
+	if err := u.writeSupportBundle(path); err != nil {
+		u.app.logger.Error().Err(err).Msgf("Failed to export support bundle to '%s'", path)
+		return "", err
+	}
+	return path, nil
+}
+
+func (u *UIService) writeSupportBundle(path string) error {
+	files := make(map[string]any, 3)
+
+	// an unreachable server is exactly when a support bundle is needed, so don't fail the export
+	diag, err := u.casparCGClient.GetDiagnostics()
+	if err != nil {
+		files["diagnostics.json"] = map[string]string{"error": err.Error()}
+	} else {
+		files["diagnostics.json"] = diag
+	}
+
+	// operators send the bundle to support, so it must not contain credentials
+	files["config.json"] = u.app.cfg.Redacted()
+
+	layout, err := LoadLayout()
+	if err != nil {
+		files["layout.json"] = map[string]string{"error": err.Error()}
+	} else {
+		files["layout.json"] = layout
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+	for _, name := range []string{"diagnostics.json", "config.json", "layout.json"} {
+		data, err := json.MarshalIndent(files[name], "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal %s: %w", name, err)
+		}
+		w, err := zw.Create(name)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	return f.Close()
+}
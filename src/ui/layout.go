@@ -3,6 +3,8 @@ package ui
 import (
 	"encoding/json"
 	"os"
+
+	"github.com/overlayfox/caspaw-cg/src/types"
 )
 
 type FieldConfig struct {
@@ -14,6 +16,15 @@ type FieldConfig struct {
 	Value     string `json:"value,omitempty"`
 	Range     string `json:"range,omitempty"`
 	Offset    int    `json:"offset,omitempty"`
+	// Render selects formatted, unformatted or formula values, empty reads formatted values
+	Render types.ValueRender `json:"render,omitempty"`
+	// Rich also sends the cell's colours, hyperlink and note to the template
+	Rich bool `json:"rich,omitempty"`
+	// Transforms format the value in the backend before it is sent to the template, the frontend sends
+	// them with every take as FieldTransforms
+	Transforms []types.Transform `json:"transforms,omitempty"`
+	// ColumnTransforms format single columns of multi-column ranges instead of Transforms
+	ColumnTransforms map[string][]types.Transform `json:"columnTransforms,omitempty"`
 }
 
 type WidgetConfig struct {
@@ -51,22 +62,40 @@ type MediaWidgetConfig struct {
 	Loop        bool   `json:"loop"`
 }
 
+type ProducerWidgetConfig struct {
+	ID          string             `json:"id"`
+	X           int                `json:"x"`
+	Y           int                `json:"y"`
+	W           int                `json:"w"`
+	H           int                `json:"h"`
+	Name        string             `json:"name,omitempty"`
+	Kind        types.ProducerKind `json:"kind"`
+	Source      string             `json:"source"`
+	Layer       int                `json:"layer"`
+	Channel     int                `json:"channel"`
+	ChannelExpr string             `json:"channelExpr,omitempty"`
+	Delay       int                `json:"delay,omitempty"`
+}
+
 type GroupConfig struct {
-	ID           string              `json:"id"`
-	X            int                 `json:"x"`
-	Y            int                 `json:"y"`
-	W            int                 `json:"w"`
-	H            int                 `json:"h"`
-	Name         string              `json:"name"`
-	Widgets      []WidgetConfig      `json:"widgets"`
-	MediaWidgets []MediaWidgetConfig `json:"mediaWidgets,omitempty"`
+	ID              string                 `json:"id"`
+	X               int                    `json:"x"`
+	Y               int                    `json:"y"`
+	W               int                    `json:"w"`
+	H               int                    `json:"h"`
+	Name            string                 `json:"name"`
+	Widgets         []WidgetConfig         `json:"widgets"`
+	MediaWidgets    []MediaWidgetConfig    `json:"mediaWidgets,omitempty"`
+	ProducerWidgets []ProducerWidgetConfig `json:"producerWidgets,omitempty"`
 }
 
 type LayoutConfig struct {
-	Version      int                 `json:"version"`
-	Widgets      []WidgetConfig      `json:"widgets"`
-	Groups       []GroupConfig       `json:"groups,omitempty"`
-	MediaWidgets []MediaWidgetConfig `json:"mediaWidgets,omitempty"`
+	Version         int                    `json:"version"`
+	Transforms      types.TransformSet     `json:"transforms,omitempty"` // shared by fields through named transforms
+	Widgets         []WidgetConfig         `json:"widgets"`
+	Groups          []GroupConfig          `json:"groups,omitempty"`
+	MediaWidgets    []MediaWidgetConfig    `json:"mediaWidgets,omitempty"`
+	ProducerWidgets []ProducerWidgetConfig `json:"producerWidgets,omitempty"`
 }
 
 const layoutFileName = "layout.json"
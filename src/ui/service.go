@@ -2,12 +2,16 @@ package ui
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"maps"
+	"math"
 	"sync"
 	"time"
 
 	"github.com/overlayfox/casparcg-amcp-go/types/responses"
 
+	casparcg "github.com/overlayfox/caspaw-cg/src/caspar"
 	"github.com/overlayfox/caspaw-cg/src/types"
 )
 
@@ -15,34 +19,69 @@ import (
 type UIService struct {
 	app               *App
 	datasourceManager types.DatasourceManager
+	variables         types.VariablesDataSource
+	coercer           types.Coercer
 	casparCGClient    types.CasparCGClient
 	updateHandler     *UpdateHandler
 
+	writeMtx sync.Mutex // serializes read-modify-writes such as increments
+
+	primes    map[string]string // map[datasource]subscription of the last PrimeDataSource call
+	primesMtx sync.Mutex
+
+	transforms *fieldTransforms
+
 	wg     sync.WaitGroup
 	ctx    context.Context
 	cancel context.CancelFunc
 }
 
-func NewUIService(upstreamCtx context.Context, app *App, datasourceManager types.DatasourceManager, casparCGClients types.CasparCGClient) *UIService {
+func NewUIService(upstreamCtx context.Context, app *App, datasourceManager types.DatasourceManager, variables types.VariablesDataSource, coercer types.Coercer, casparCGClients types.CasparCGClient) *UIService {
 	ctx, cancel := context.WithCancel(upstreamCtx)
-	return &UIService{
+	u := &UIService{
 		app:               app,
 		datasourceManager: datasourceManager,
+		variables:         variables,
+		coercer:           coercer,
 		casparCGClient:    casparCGClients,
-		updateHandler:     NewUpdateHandler(ctx, app.logger, datasourceManager, casparCGClients),
+		updateHandler:     NewUpdateHandler(ctx, app.logger, datasourceManager, app.eventProcessor, casparCGClients),
+		primes:            make(map[string]string),
+		transforms:        &fieldTransforms{coercer: coercer},
 		ctx:               ctx,
 		cancel:            cancel,
 	}
+
+	// named transforms apply before the frontend loads the layout
+	if layout, err := LoadLayout(); err != nil {
+		app.logger.Warn().Err(err).Msg("Failed to load named transforms from layout")
+	} else {
+		u.transforms.load(layout)
+	}
+	return u
 }
 
 func (u *UIService) SaveLayout(config LayoutConfig) error {
 	u.app.logger.Info().Msg("Saving layout configuration")
-	return SaveLayout(config)
+	if err := SaveLayout(config); err != nil {
+		return err
+	}
+	u.transforms.load(config)
+	return nil
 }
 
 func (u *UIService) LoadLayout() (LayoutConfig, error) {
 	u.app.logger.Info().Msg("Loading layout configuration")
-	return LoadLayout()
+	config, err := LoadLayout()
+	if err != nil {
+		return config, err
+	}
+	u.transforms.load(config)
+	return config, nil
+}
+
+// PreviewTransforms runs transforms on a sample value, so the widget editor can show the result without a server
+func (u *UIService) PreviewTransforms(value any, transforms []types.Transform) (any, error) {
+	return u.transforms.preview(value, transforms)
 }
 
 func (u *UIService) GetDataSources() []string {
@@ -55,6 +94,62 @@ func (u *UIService) GetDataSources() []string {
 	return names
 }
 
+func (u *UIService) GetCasparCGCapabilities() types.ServerCapabilities {
+	return u.casparCGClient.GetCapabilities()
+}
+
+// GetCasparCGTemplateInfo returns the template details CG INFO reports for the layer, servers from 2.5 on fail with a clear message
+func (u *UIService) GetCasparCGTemplateInfo(channel, layer int) ([]string, error) {
+	info, err := u.casparCGClient.GetTemplateInfo(channel, layer)
+	if err != nil {
+		u.app.logger.Error().Err(err).Msgf("Failed to get template info for layer %d on channel %d", layer, channel)
+		return nil, err
+	}
+	return info, nil
+}
+
+// GetActiveCasparCGServer returns the role of the live server, which is always the primary without a backup configured
+func (u *UIService) GetActiveCasparCGServer() types.CasparCGServerRole {
+	redundant, ok := u.casparCGClient.(types.RedundantCasparCGClient)
+	if !ok {
+		return types.CasparCGServerRolePrimary
+	}
+	return redundant.GetActive()
+}
+
+// SetActiveCasparCGServer manually switches the live server of a main/backup pair
+func (u *UIService) SetActiveCasparCGServer(role types.CasparCGServerRole) error {
+	redundant, ok := u.casparCGClient.(types.RedundantCasparCGClient)
+	if !ok {
+		return errors.New("no backup CasparCG server configured")
+	}
+	if err := redundant.SetActive(role); err != nil {
+		u.app.logger.Error().Err(err).Msgf("Failed to switch active CasparCG server to '%s'", role)
+		return err
+	}
+	return nil
+}
+
+// DiscoverCasparCGServers probes subnet (e.g. "192.168.1.0/24") for CasparCG servers on the configured port.
+// If subnet is empty the configured discovery_subnet is used.
+func (u *UIService) DiscoverCasparCGServers(subnet string) ([]types.DiscoveredCasparCGServer, error) {
+	cfg := u.app.cfg.CasparCGClient
+	if subnet == "" {
+		subnet = cfg.DiscoverySubnet
+	}
+	if subnet == "" {
+		return nil, errors.New("no subnet given and no discovery_subnet configured")
+	}
+
+	u.app.logger.Info().Msgf("Discovering CasparCG servers in '%s' on port %d", subnet, cfg.Port)
+	servers, err := casparcg.Discover(u.ctx, subnet, cfg.Port, 500*time.Millisecond)
+	if err != nil {
+		u.app.logger.Error().Err(err).Msgf("Failed to discover CasparCG servers in '%s'", subnet)
+		return nil, err
+	}
+	return servers, nil
+}
+
 func (u *UIService) GetCasparCGTemplates() []string {
 	templates, err := u.casparCGClient.GetTemplates()
 	if err != nil {
@@ -83,6 +178,12 @@ func (u *UIService) GetCasparCGMediaInfo(filename string) (responses.CINF, error
 }
 
 func (u *UIService) PushCasparCGData(template string, layer int, channels []int, data map[string]any, sizing types.Sizing, delay time.Duration) {
+	u.PushCasparCGDataWithTransforms(template, layer, channels, data, nil, sizing, delay)
+}
+
+// PushCasparCGDataWithTransforms sends data to the template, formatted by the transforms of the widget's fields
+func (u *UIService) PushCasparCGDataWithTransforms(template string, layer int, channels []int, data map[string]any, transforms map[string]FieldTransforms, sizing types.Sizing, delay time.Duration) {
+	data = u.transforms.apply(u.app.logger, template, data, transforms)
 	u.wg.Go(func() {
 		err := u.casparCGClient.AddCGData(template, layer, channels, data, sizing, delay)
 		if err != nil {
@@ -111,12 +212,18 @@ func (u *UIService) NextCasparCGData(template string, layer int, channels []int,
 
 // RangeField describes a single template field that should be continuously
 // resolved from a range of locations in a data source.
+// Ranges spanning several columns resolve to an object with one member per column, named by the
+// column letters or, if HeaderRow is set, by the primed cells of that sheet row above the range.
 type RangeField struct {
 	CasparKey string
 	Type      types.DataType
 	Source    string
 	Range     string
-	Offset    int
+	Offset    int // 0-based row to start at
+	HeaderRow int // 1-based sheet row holding the column names, 0 uses the column letters
+	// Render and Rich select how the cells are read, see types.Location
+	Render types.ValueRender
+	Rich   bool
 }
 
 // UpdateCasparCGData pushes an initial snapshot of literalData plus the current values of
@@ -125,7 +232,18 @@ type RangeField struct {
 //
 // It returns a unique identifier for the update job.
 func (u *UIService) UpdateCasparCGData(template string, layer int, channels []int, literalData map[string]any, rangeFields []RangeField, sizing types.Sizing, playInDelay, updateInterval time.Duration) (uuid string, err error) {
+	return u.UpdateCasparCGDataWithTransforms(template, layer, channels, literalData, rangeFields, nil, sizing, playInDelay, updateInterval)
+}
+
+// UpdateCasparCGDataWithTransforms is UpdateCasparCGData with the transforms of the widget's fields
+// applied before the initial push and every update.
+func (u *UIService) UpdateCasparCGDataWithTransforms(template string, layer int, channels []int, literalData map[string]any, rangeFields []RangeField, transforms map[string]FieldTransforms, sizing types.Sizing, playInDelay, updateInterval time.Duration) (uuid string, err error) {
 	casparMaps := make(map[string]*Resolver, len(rangeFields))
+	defer func() {
+		if err != nil { // release what the resolvers set up so far subscribed
+			unsubscribe(u.app.logger, casparMaps)
+		}
+	}()
 	for _, rf := range rangeFields {
 		dataRange, err := types.NewRange(rf.Range)
 		if err != nil {
@@ -134,6 +252,8 @@ func (u *UIService) UpdateCasparCGData(template string, layer int, channels []in
 		}
 		for i := range dataRange.Locations {
 			dataRange.Locations[i].Type = rf.Type
+			dataRange.Locations[i].Render = rf.Render
+			dataRange.Locations[i].Rich = rf.Rich
 		}
 
 		ds, err := u.datasourceManager.GetDataSource(rf.Source)
@@ -142,8 +262,31 @@ func (u *UIService) UpdateCasparCGData(template string, layer int, channels []in
 			return "", err
 		}
 
-		resolver := NewResolver(ds, dataRange, rf.Offset)
+		resolver := NewResolver(ds, dataRange, rf.Offset, u.coercer)
+		var header []types.Location
+		if rf.HeaderRow > 0 {
+			header = dataRange.HeaderLocations(rf.HeaderRow)
+		}
+		if err := resolver.Subscribe(header...); err != nil {
+			u.app.logger.Error().Err(err).Str("range", rf.Range).Msgf("Failed to subscribe to datasource '%s'", rf.Source)
+			return "", err
+		}
 		casparMaps[rf.CasparKey] = &resolver
+
+		if rf.HeaderRow > 0 {
+			names := make([]string, 0, len(header))
+			for _, loc := range header {
+				data, err := ds.Get(loc)
+				if err != nil {
+					u.app.logger.Error().Err(err).Str("range", rf.Range).Msg("Failed to get column name from header row")
+					return "", err
+				}
+				names = append(names, fmt.Sprintf("%v", data.Value))
+			}
+			if err := resolver.SetColumnNames(names); err != nil {
+				return "", err
+			}
+		}
 	}
 
 	resolvedData := make(map[string]any, len(literalData)+len(casparMaps))
@@ -156,9 +299,12 @@ func (u *UIService) UpdateCasparCGData(template string, layer int, channels []in
 		resolvedData[casparKey] = value
 		resolver.Advance()
 	}
-	u.PushCasparCGData(template, layer, channels, resolvedData, sizing, playInDelay)
+	u.PushCasparCGDataWithTransforms(template, layer, channels, resolvedData, transforms, sizing, playInDelay)
 
-	uuid = u.updateHandler.AddUpdateJob(template, layer, channels, u.casparCGClient, casparMaps, updateInterval)
+	format := func(data map[string]any) map[string]any {
+		return u.transforms.apply(u.app.logger, template, data, transforms)
+	}
+	uuid = u.updateHandler.AddUpdateJob(template, layer, channels, u.casparCGClient, casparMaps, format, updateInterval)
 	return uuid, nil
 }
 
@@ -167,21 +313,60 @@ func (u *UIService) RemoveUpdateJob(uuid string) error {
 	return u.updateHandler.RemoveUpdateJob(uuid)
 }
 
-func (u *UIService) PrimeDataSource(name string, locations []types.Location) error {
+// SubscribeDataSource primes locations of a datasource, e.g. for a widget, and returns the handle to unsubscribe them.
+// Subscriptions are reference counted, unsubscribing only removes locations no other widget or update job uses.
+func (u *UIService) SubscribeDataSource(name string, locations []types.Location) (string, error) {
+	ds, err := u.datasourceManager.GetDataSource(name)
+	if err != nil {
+		u.app.logger.Error().Err(err).Msgf("Failed to get datasource '%s'", name)
+		return "", err
+	}
+
+	u.app.logger.Info().Msgf("Subscribing to datasource '%s' with locations: %v", name, locations)
+	handle, err := ds.Subscribe(locations)
+	if err != nil {
+		u.app.logger.Error().Err(err).Msgf("Failed to subscribe to datasource '%s'", name)
+		return "", err
+	}
+	return handle, nil
+}
+
+func (u *UIService) UnsubscribeDataSource(name, handle string) error {
 	ds, err := u.datasourceManager.GetDataSource(name)
 	if err != nil {
 		u.app.logger.Error().Err(err).Msgf("Failed to get datasource '%s'", name)
 		return err
 	}
 
-	u.app.logger.Info().Msgf("Priming datasource '%s' with locations: %v", name, locations)
-	if err := ds.Prime(locations); err != nil { // TODO: add removal of primed data when a new PrimDataSource is called
-		u.app.logger.Error().Err(err).Msgf("Failed to prime datasource '%s'", name)
+	u.app.logger.Info().Msgf("Unsubscribing '%s' from datasource '%s'", handle, name)
+	if err := ds.Unsubscribe(handle); err != nil {
+		u.app.logger.Error().Err(err).Msgf("Failed to unsubscribe from datasource '%s'", name)
 		return err
 	}
 	return nil
 }
 
+// PrimeDataSource replaces the locations primed by the previous call for the same datasource,
+// locations subscribed through SubscribeDataSource or by update jobs are kept.
+func (u *UIService) PrimeDataSource(name string, locations []types.Location) error {
+	u.primesMtx.Lock()
+	defer u.primesMtx.Unlock()
+
+	handle, err := u.SubscribeDataSource(name, locations)
+	if err != nil {
+		return err
+	}
+	if previous, ok := u.primes[name]; ok {
+		if err := u.UnsubscribeDataSource(name, previous); err != nil {
+			u.app.logger.Warn().Err(err).Msgf("Failed to remove previously primed locations of datasource '%s'", name)
+		}
+	}
+	u.primes[name] = handle
+	return nil
+}
+
+// GetDataSourceValue returns the value of a location converted to the location's type. If the value
+// doesn't match the type the error says why and the data holds the value as the datasource returned it.
 func (u *UIService) GetDataSourceValue(name string, location types.Location) (types.Data, error) {
 	ds, err := u.datasourceManager.GetDataSource(name)
 	if err != nil {
@@ -190,26 +375,329 @@ func (u *UIService) GetDataSourceValue(name string, location types.Location) (ty
 	}
 
 	u.app.logger.Info().Msgf("Getting value from datasource '%s' for location: %v", name, location)
-	data, err := ds.Get(location.Key)
+	data, err := ds.Get(location)
 	if err != nil {
 		u.app.logger.Error().Err(err).Msgf("Failed to get value from datasource '%s' for location: %v", name, location)
 		return data, err
 	}
+	value, err := u.coercer.Coerce(data.Value, location.Type)
+	if err != nil {
+		return data, fmt.Errorf("%s: %w", location.Key, err)
+	}
+	data.Value = value
 	return data, nil
 }
 
+// FieldError reports a field whose current value doesn't match its data type
+type FieldError struct {
+	Key   string
+	Type  types.DataType
+	Error string
+}
+
+// ValidateDataSourceFields converts the current values of primed locations to their types and returns
+// the ones that fail, so the widget editor can flag them before take
+func (u *UIService) ValidateDataSourceFields(name string, locations []types.Location) ([]FieldError, error) {
+	ds, err := u.datasourceManager.GetDataSource(name)
+	if err != nil {
+		u.app.logger.Error().Err(err).Msgf("Failed to get datasource '%s'", name)
+		return nil, err
+	}
+
+	fieldErrors := make([]FieldError, 0)
+	for _, loc := range locations {
+		data, err := ds.Get(loc)
+		if err == nil {
+			_, err = u.coercer.Coerce(data.Value, loc.Type)
+		}
+		if err != nil {
+			fieldErrors = append(fieldErrors, FieldError{
+				Key:   loc.Key,
+				Type:  loc.Type,
+				Error: err.Error(),
+			})
+		}
+	}
+	return fieldErrors, nil
+}
+
+// GetDataSourcePollInterval returns the current poll interval of a polling datasource
+func (u *UIService) GetDataSourcePollInterval(name string) (time.Duration, error) {
+	ds, err := u.pollingDataSource(name)
+	if err != nil {
+		return 0, err
+	}
+	return ds.GetPollInterval(), nil
+}
+
+// SetDataSourcePollInterval changes the poll interval of a polling datasource until the application restarts
+func (u *UIService) SetDataSourcePollInterval(name string, interval time.Duration) error {
+	ds, err := u.pollingDataSource(name)
+	if err != nil {
+		return err
+	}
+
+	u.app.logger.Info().Msgf("Setting poll interval of datasource '%s' to %s", name, interval)
+	if err := ds.SetPollInterval(interval); err != nil {
+		u.app.logger.Error().Err(err).Msgf("Failed to set poll interval of datasource '%s'", name)
+		return err
+	}
+	return nil
+}
+
+// SetDataSourceOnAir tells a polling datasource that a widget bound to it was taken (true) or cleared (false),
+// it polls faster while on air. Every take has to be matched by a clear.
+func (u *UIService) SetDataSourceOnAir(name string, onAir bool) error {
+	ds, err := u.pollingDataSource(name)
+	if err != nil {
+		return err
+	}
+	ds.SetOnAir(onAir)
+	return nil
+}
+
+// IsDataSourceSignedIn reports whether the operator is signed in to a datasource, datasources without
+// a sign-in always are
+func (u *UIService) IsDataSourceSignedIn(name string) (bool, error) {
+	ds, err := u.datasourceManager.GetDataSource(name)
+	if err != nil {
+		u.app.logger.Error().Err(err).Msgf("Failed to get datasource '%s'", name)
+		return false, err
+	}
+	signIn, ok := ds.(types.SignInDataSource)
+	return !ok || signIn.SignedIn(), nil
+}
+
+// SignInDataSource opens the browser for the operator to sign in to a datasource, e.g. after the
+// sign-in started with the application timed out
+func (u *UIService) SignInDataSource(name string) error {
+	ds, err := u.datasourceManager.GetDataSource(name)
+	if err != nil {
+		u.app.logger.Error().Err(err).Msgf("Failed to get datasource '%s'", name)
+		return err
+	}
+	signIn, ok := ds.(types.SignInDataSource)
+	if !ok {
+		return fmt.Errorf("datasource '%s' has no sign-in", name)
+	}
+	u.app.logger.Info().Msgf("Signing in to datasource '%s'", name)
+	return signIn.SignIn()
+}
+
+func (u *UIService) pollingDataSource(name string) (types.PollingDataSource, error) {
+	ds, err := u.datasourceManager.GetDataSource(name)
+	if err != nil {
+		u.app.logger.Error().Err(err).Msgf("Failed to get datasource '%s'", name)
+		return nil, err
+	}
+	polling, ok := ds.(types.PollingDataSource)
+	if !ok {
+		return nil, fmt.Errorf("datasource '%s' does not poll", name)
+	}
+	return polling, nil
+}
+
+// SetDataSourceValue writes value to a location of a writable datasource, e.g. to mark a lower third as aired in the sheet
+func (u *UIService) SetDataSourceValue(name, key string, value any) error {
+	ds, err := u.writableDataSource(name)
+	if err != nil {
+		return err
+	}
+
+	u.app.logger.Info().Msgf("Setting '%s' of datasource '%s' to '%v'", key, name, value)
+	if err := ds.Set(key, value); err != nil {
+		u.app.logger.Error().Err(err).Msgf("Failed to set '%s' of datasource '%s'", key, name)
+		return err
+	}
+	return nil
+}
+
+// IncrementDataSourceValue adds delta to the number at a location of a writable datasource, e.g. +1 on
+// the home score, and returns the new value. The current value is read straight from the datasource and
+// converted like any number field, empty cells count as 0.
+func (u *UIService) IncrementDataSourceValue(name, key string, delta float64) (any, error) {
+	ds, err := u.writableDataSource(name)
+	if err != nil {
+		return nil, err
+	}
+
+	u.writeMtx.Lock()
+	defer u.writeMtx.Unlock()
+
+	value, err := ds.Modify(key, func(current any) (any, error) {
+		number, err := u.coercer.Coerce(current, types.DataTypeFloat)
+		if err != nil {
+			return nil, fmt.Errorf("cannot increment '%s' of datasource '%s': %w", key, name, err)
+		}
+		sum := delta
+		if number != nil {
+			sum += number.(float64)
+		}
+		if sum == math.Trunc(sum) && math.Abs(sum) < 1<<53 {
+			return int64(sum), nil
+		}
+		return sum, nil
+	})
+	if err != nil {
+		u.app.logger.Error().Err(err).Msgf("Failed to increment '%s' of datasource '%s'", key, name)
+		return nil, err
+	}
+	u.app.logger.Info().Msgf("Incremented '%s' of datasource '%s' by %v to '%v'", key, name, delta, value)
+	return value, nil
+}
+
+func (u *UIService) writableDataSource(name string) (types.WritableDataSource, error) {
+	ds, err := u.datasourceManager.GetDataSource(name)
+	if err != nil {
+		u.app.logger.Error().Err(err).Msgf("Failed to get datasource '%s'", name)
+		return nil, err
+	}
+	writable, ok := ds.(types.WritableDataSource)
+	if !ok {
+		return nil, fmt.Errorf("datasource '%s' is read-only", name)
+	}
+	return writable, nil
+}
+
+// ListDataSourceSheets returns the tabs of a spreadsheet datasource for location pickers
+func (u *UIService) ListDataSourceSheets(name string) ([]types.SheetInfo, error) {
+	ds, err := u.browsableDataSource(name)
+	if err != nil {
+		return nil, err
+	}
+	sheets, err := ds.ListSheets()
+	if err != nil {
+		u.app.logger.Error().Err(err).Msgf("Failed to list sheets of datasource '%s'", name)
+		return nil, err
+	}
+	return sheets, nil
+}
+
+// ListDataSourceHeaders returns the column names in the first row of a sheet, e.g. for record locations
+func (u *UIService) ListDataSourceHeaders(name, sheet string) ([]string, error) {
+	ds, err := u.browsableDataSource(name)
+	if err != nil {
+		return nil, err
+	}
+	headers, err := ds.ListHeaders(sheet)
+	if err != nil {
+		u.app.logger.Error().Err(err).Msgf("Failed to list headers of sheet '%s' of datasource '%s'", sheet, name)
+		return nil, err
+	}
+	return headers, nil
+}
+
+func (u *UIService) ListDataSourceNamedRanges(name string) ([]types.NamedRange, error) {
+	ds, err := u.browsableDataSource(name)
+	if err != nil {
+		return nil, err
+	}
+	namedRanges, err := ds.ListNamedRanges()
+	if err != nil {
+		u.app.logger.Error().Err(err).Msgf("Failed to list named ranges of datasource '%s'", name)
+		return nil, err
+	}
+	return namedRanges, nil
+}
+
+// ValidateDataSourceKey checks a location key while editing a widget instead of at take time
+func (u *UIService) ValidateDataSourceKey(name, key string) error {
+	ds, err := u.browsableDataSource(name)
+	if err != nil {
+		return err
+	}
+	return ds.ValidateKey(key)
+}
+
+func (u *UIService) browsableDataSource(name string) (types.BrowsableDataSource, error) {
+	ds, err := u.datasourceManager.GetDataSource(name)
+	if err != nil {
+		u.app.logger.Error().Err(err).Msgf("Failed to get datasource '%s'", name)
+		return nil, err
+	}
+	browsable, ok := ds.(types.BrowsableDataSource)
+	if !ok {
+		return nil, fmt.Errorf("datasource '%s' can't list its structure", name)
+	}
+	return browsable, nil
+}
+
+// ListVariables returns all operator-edited variables
+func (u *UIService) ListVariables() map[string]any {
+	return u.variables.List()
+}
+
+// SetVariable creates or updates a variable, widgets and update jobs using it are updated immediately
+func (u *UIService) SetVariable(key string, value any) error {
+	u.app.logger.Info().Msgf("Setting variable '%s' to '%v'", key, value)
+	if err := u.variables.Set(key, value); err != nil {
+		u.app.logger.Error().Err(err).Msgf("Failed to set variable '%s'", key)
+		return err
+	}
+	return nil
+}
+
+func (u *UIService) DeleteVariable(key string) error {
+	u.app.logger.Info().Msgf("Deleting variable '%s'", key)
+	if err := u.variables.Delete(key); err != nil {
+		u.app.logger.Error().Err(err).Msgf("Failed to delete variable '%s'", key)
+		return err
+	}
+	return nil
+}
+
+func (u *UIService) StartStopwatch(source, name string) error {
+	return u.controlStopwatch(source, name, "start", types.TimingDataSource.StartStopwatch)
+}
+
+func (u *UIService) StopStopwatch(source, name string) error {
+	return u.controlStopwatch(source, name, "stop", types.TimingDataSource.StopStopwatch)
+}
+
+func (u *UIService) ResetStopwatch(source, name string) error {
+	return u.controlStopwatch(source, name, "reset", types.TimingDataSource.ResetStopwatch)
+}
+
+// SetStopwatch sets the elapsed time of a stopwatch, e.g. to correct it after a missed start
+func (u *UIService) SetStopwatch(source, name string, elapsed time.Duration) error {
+	return u.controlStopwatch(source, name, "set", func(ds types.TimingDataSource, name string) error {
+		return ds.SetStopwatch(name, elapsed)
+	})
+}
+
+func (u *UIService) controlStopwatch(source, name, action string, control func(ds types.TimingDataSource, name string) error) error {
+	ds, err := u.datasourceManager.GetDataSource(source)
+	if err != nil {
+		u.app.logger.Error().Err(err).Msgf("Failed to get datasource '%s'", source)
+		return err
+	}
+	timingDS, ok := ds.(types.TimingDataSource)
+	if !ok {
+		return fmt.Errorf("datasource '%s' has no stopwatches", source)
+	}
+
+	u.app.logger.Info().Msgf("Stopwatch '%s' of datasource '%s': %s", name, source, action)
+	if err := control(timingDS, name); err != nil {
+		u.app.logger.Error().Err(err).Msgf("Failed to %s stopwatch '%s' of datasource '%s'", action, name, source)
+		return err
+	}
+	return nil
+}
+
 type CGDataGroup struct {
 	Template string
 	Layer    int
 	Channels []int
 	Data     map[string]any
-	Sizing   types.Sizing
-	Delay    time.Duration
+	// Transforms format the data, map[field key]transforms of the widget's field
+	Transforms map[string]FieldTransforms
+	Sizing     types.Sizing
+	Delay      time.Duration
 }
 
 func (u *UIService) PushCasparCGDataGroup(dataGroups []CGDataGroup) {
 	for _, data := range dataGroups {
-		u.PushCasparCGData(data.Template, data.Layer, data.Channels, data.Data, data.Sizing, data.Delay)
+		u.PushCasparCGDataWithTransforms(data.Template, data.Layer, data.Channels, data.Data, data.Transforms, data.Sizing, data.Delay)
 	}
 }
 
@@ -237,6 +725,24 @@ func (u *UIService) StopCasparCGMedia(layer int, channels []int, delay time.Dura
 	})
 }
 
+// PlayCasparCGProducer validates source for the given producer kind and plays it out like a media file.
+// Producers are stopped through StopCasparCGMedia.
+func (u *UIService) PlayCasparCGProducer(kind types.ProducerKind, source string, layer int, channels []int, delay time.Duration) error {
+	producer, err := types.NewProducer(kind, source)
+	if err != nil {
+		u.app.logger.Error().Err(err).Msgf("Invalid %s producer '%s'", kind, source)
+		return err
+	}
+
+	u.wg.Go(func() {
+		err := u.casparCGClient.PlayProducer(producer, layer, channels, delay)
+		if err != nil {
+			u.app.logger.Error().Err(err).Msgf("Failed to play %s producer '%s' on layer %d, channels %v", kind, source, layer, channels)
+		}
+	})
+	return nil
+}
+
 func (u *UIService) ClearChannels(channels []int) {
 	u.wg.Go(func() {
 		u.casparCGClient.ClearChannels(channels)
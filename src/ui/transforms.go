@@ -0,0 +1,101 @@
+package ui
+
+import (
+	"sync"
+
+	"github.com/rs/zerolog"
+
+	"github.com/overlayfox/caspaw-cg/src/types"
+)
+
+// FieldTransforms format the value of a widget field before it is sent to the template. The frontend
+// sends them with every take and update, so unsaved edits apply and widgets sharing a template and
+// layer keep their own formatting.
+type FieldTransforms struct {
+	Transforms []types.Transform
+	// Columns format single columns of multi-column ranges, columns without an entry use Transforms
+	Columns map[string][]types.Transform
+}
+
+// richValue is a cell primed with Rich, transforms format its value and keep its formatting
+type richValue map[string]any
+
+// fieldTransforms holds the named transforms of the saved layout, which field transforms refer to
+type fieldTransforms struct {
+	named types.TransformSet
+	// coercer reads numbers and dates with the configured decimal separator and timezone
+	coercer types.Coercer
+	mtx     sync.RWMutex
+}
+
+func (f *fieldTransforms) load(layout LayoutConfig) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.named = layout.Transforms
+}
+
+// apply returns a copy of data with the transforms of its fields applied. Values failing a transform
+// are passed on as they are.
+func (f *fieldTransforms) apply(logger zerolog.Logger, template string, data map[string]any, fields map[string]FieldTransforms) map[string]any {
+	if len(fields) == 0 {
+		return data
+	}
+
+	f.mtx.RLock()
+	defer f.mtx.RUnlock()
+
+	result := make(map[string]any, len(data))
+	for key, value := range data {
+		result[key] = value
+		field, ok := fields[key]
+		if !ok {
+			continue
+		}
+		result[key] = f.transform(value, field, func(err error) {
+			logger.Warn().Err(err).Str("template", template).Str("field", key).Msg("Failed to transform field value")
+		})
+	}
+	return result
+}
+
+// transform formats a field value: every column of a row object, the value of a rich cell or the value itself.
+// The caller must hold f.mtx.
+func (f *fieldTransforms) transform(value any, field FieldTransforms, onError func(err error)) any {
+	switch v := value.(type) {
+	case map[string]any:
+		row := make(map[string]any, len(v))
+		for column, cell := range v {
+			steps, ok := field.Columns[column]
+			if !ok {
+				steps = field.Transforms
+			}
+			row[column] = f.transform(cell, FieldTransforms{Transforms: steps}, onError)
+		}
+		return row
+	case richValue:
+		rich := make(richValue, len(v))
+		for name, member := range v {
+			rich[name] = member
+		}
+		rich["value"] = f.transform(v["value"], FieldTransforms{Transforms: field.Transforms}, onError)
+		return rich
+	}
+
+	if len(field.Transforms) == 0 {
+		return value
+	}
+	transformed, err := f.named.Apply(value, field.Transforms, f.coercer)
+	if err != nil {
+		onError(err)
+		return value
+	}
+	return transformed
+}
+
+// preview runs steps with the named transforms of the layout
+func (f *fieldTransforms) preview(value any, steps []types.Transform) (any, error) {
+	f.mtx.RLock()
+	defer f.mtx.RUnlock()
+
+	return f.named.Apply(value, steps, f.coercer)
+}
@@ -0,0 +1,86 @@
+package ui
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	"github.com/overlayfox/caspaw-cg/src/types"
+)
+
+func TestFieldTransformsApply(t *testing.T) {
+	f := fieldTransforms{coercer: types.Coercer{DecimalSeparator: ','}}
+	f.load(LayoutConfig{Transforms: types.TransformSet{
+		"points": {{Kind: types.TransformSuffix, Text: " pts"}},
+	}})
+
+	upper := []types.Transform{{Kind: types.TransformUpper}}
+	points := []types.Transform{{Kind: types.TransformNamed, Name: "points"}}
+
+	tests := []struct {
+		name   string
+		data   map[string]any
+		fields map[string]FieldTransforms
+		want   map[string]any
+	}{
+		{
+			name: "no transforms",
+			data: map[string]any{"name": "kim"},
+			want: map[string]any{"name": "kim"},
+		},
+		{
+			name:   "plain values",
+			data:   map[string]any{"name": "kim", "team": "home"},
+			fields: map[string]FieldTransforms{"name": {Transforms: upper}},
+			want:   map[string]any{"name": "KIM", "team": "home"},
+		},
+		{
+			name:   "named",
+			data:   map[string]any{"score": int64(3)},
+			fields: map[string]FieldTransforms{"score": {Transforms: points}},
+			want:   map[string]any{"score": "3 pts"},
+		},
+		{
+			name: "row columns",
+			data: map[string]any{"standings": map[string]any{"A": "kim", "B": int64(3)}},
+			fields: map[string]FieldTransforms{"standings": {
+				Transforms: upper,
+				Columns:    map[string][]types.Transform{"B": points},
+			}},
+			want: map[string]any{"standings": map[string]any{"A": "KIM", "B": "3 pts"}},
+		},
+		{
+			name:   "rich value keeps its formatting",
+			data:   map[string]any{"name": richValue{"value": "kim", "textColor": "#FF0000"}},
+			fields: map[string]FieldTransforms{"name": {Transforms: upper}},
+			want:   map[string]any{"name": richValue{"value": "KIM", "textColor": "#FF0000"}},
+		},
+		{
+			name:   "rich cells of a row",
+			data:   map[string]any{"standings": map[string]any{"A": richValue{"value": "kim"}}},
+			fields: map[string]FieldTransforms{"standings": {Transforms: upper}},
+			want:   map[string]any{"standings": map[string]any{"A": richValue{"value": "KIM"}}},
+		},
+		{
+			name:   "configured decimal separator",
+			data:   map[string]any{"score": "1,250"},
+			fields: map[string]FieldTransforms{"score": {Transforms: []types.Transform{{Kind: types.TransformNumber, Decimals: 1}}}},
+			want:   map[string]any{"score": "1.3"},
+		},
+		{
+			name:   "failing transform passes the value on",
+			data:   map[string]any{"score": "n/a"},
+			fields: map[string]FieldTransforms{"score": {Transforms: []types.Transform{{Kind: types.TransformNumber}}}},
+			want:   map[string]any{"score": "n/a"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := f.apply(zerolog.Nop(), "lower-third", tt.data, tt.fields)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("apply() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
@@ -3,6 +3,8 @@ package ui
 import (
 	"context"
 	"errors"
+	"fmt"
+	"slices"
 	"sync"
 	"time"
 
@@ -12,35 +14,134 @@ import (
 	"github.com/overlayfox/caspaw-cg/src/types"
 )
 
+// Resolver walks a range row by row. Single column ranges resolve to the value of the current cell,
+// wider ranges to a map of column name to value for every cell of the current row.
+// Cells primed with Rich resolve to an object holding the value and the cell's formatting.
 type Resolver struct {
-	offset     int
+	offset     int // 0-based row
 	datasource types.DataSource
 	dataRange  types.Range
+	columns    []string // names of the range's columns, the column letters unless named from a header row
+	coercer    types.Coercer
+
+	subscription string // handle of the range's locations subscribed while the resolver is in use
+	shown        int    // 0-based row GetData read last, the row the template currently shows
 }
 
-func NewResolver(datasource types.DataSource, dataRange types.Range, offset int) Resolver {
+func NewResolver(datasource types.DataSource, dataRange types.Range, offset int, coercer types.Coercer) Resolver {
 	return Resolver{
 		datasource: datasource,
 		dataRange:  dataRange,
 		offset:     offset,
+		columns:    dataRange.ColumnLetters(),
+		coercer:    coercer,
 	}
 }
 
+// SetColumnNames names the range's columns, e.g. from the values of a header row
+func (r *Resolver) SetColumnNames(names []string) error {
+	if len(names) != r.dataRange.Columns {
+		return fmt.Errorf("got %d column names for a range of %d columns", len(names), r.dataRange.Columns)
+	}
+	r.columns = names
+	return nil
+}
+
+// Subscribe primes the range's cells plus extra locations such as the header row, until Unsubscribe
+func (r *Resolver) Subscribe(extra ...types.Location) error {
+	locations := append(slices.Clone(r.dataRange.Locations), extra...)
+	handle, err := r.datasource.Subscribe(locations)
+	if err != nil {
+		return err
+	}
+	r.subscription = handle
+	return nil
+}
+
+func (r *Resolver) Unsubscribe() error {
+	if r.subscription == "" {
+		return nil
+	}
+	handle := r.subscription
+	r.subscription = ""
+	return r.datasource.Unsubscribe(handle)
+}
+
 func (r *Resolver) GetData() (any, error) {
-	if r.offset < 0 || r.offset >= len(r.dataRange.Locations) {
+	r.shown = r.offset
+	return r.rowData(r.offset)
+}
+
+// Current reads the row GetData read last again, e.g. after one of its cells changed
+func (r *Resolver) Current() (any, error) {
+	return r.rowData(r.shown)
+}
+
+// Shows reports whether the row the template currently shows reads key of the datasource source
+func (r *Resolver) Shows(source, key string) bool {
+	if r.datasource.GetName() != source {
+		return false
+	}
+	return slices.ContainsFunc(r.dataRange.Row(r.shown), func(loc types.Location) bool {
+		return loc.Key == key
+	})
+}
+
+func (r *Resolver) rowData(i int) (any, error) {
+	row := r.dataRange.Row(i)
+	if row == nil {
 		return nil, errors.New("offset out of range")
 	}
-	key := r.dataRange.Locations[r.offset].Key
-	data, err := r.datasource.Get(key)
+
+	if len(row) == 1 {
+		data, err := r.get(row[0])
+		return cellValue(data), err
+	}
+
+	// resolve the whole row even if a cell fails, the template still gets every other column
+	values := make(map[string]any, len(row))
+	var errs []error
+	for i, loc := range row {
+		data, err := r.get(loc)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		values[r.columns[i]] = cellValue(data)
+	}
+	return values, errors.Join(errs...)
+}
+
+// get reads a cell converted to the type of the range, values that don't convert are passed on as they are
+func (r *Resolver) get(loc types.Location) (types.Data, error) {
+	data, err := r.datasource.Get(loc)
+	if err != nil {
+		return data, err
+	}
+	value, err := r.coercer.Coerce(data.Value, loc.Type)
 	if err != nil {
-		return nil, err
+		return data, fmt.Errorf("%s: %w", loc.Key, err)
+	}
+	data.Value = value
+	return data, nil
+}
+
+// cellValue returns what a template receives for a cell, templates keying off e.g. team colours read the rich fields
+func cellValue(data types.Data) any {
+	if data.Rich == nil {
+		return data.Value
+	}
+	return richValue{
+		"value":           data.Value,
+		"backgroundColor": data.Rich.BackgroundColor,
+		"textColor":       data.Rich.TextColor,
+		"hyperlink":       data.Rich.Hyperlink,
+		"note":            data.Rich.Note,
 	}
-	return data.Value, nil
 }
 
 func (r *Resolver) Advance() {
 	r.offset++
-	if r.offset >= len(r.dataRange.Locations) {
+	if r.offset >= r.dataRange.RowCount() {
 		r.offset = 0 // Reset to the beginning if we reach the end
 	}
 }
@@ -48,12 +149,17 @@ func (r *Resolver) Advance() {
 type Update struct {
 	logger zerolog.Logger
 
+	eventProcessor types.EventProcessor
+	events         <-chan types.Event
+
 	casparCGClient types.CasparCGClient
 	template       string
 	layer          int
 	videoChannels  []int
 
 	casparMaps map[string]*Resolver // map[casparKey]*Resolver
+	format     func(data map[string]any) map[string]any
+	mtx        sync.Mutex // guards the rows the resolvers show
 
 	updateInterval time.Duration
 
@@ -62,17 +168,23 @@ type Update struct {
 	cancel context.CancelFunc
 }
 
-func NewUpdate(upstreamCtx context.Context, logger zerolog.Logger, template string, layer int, videoChannels []int, casparCGClient types.CasparCGClient, casparMaps map[string]*Resolver, updateInterval time.Duration) types.UpdateJob {
+// NewUpdate creates an update job, format transforms the resolved data before every update.
+// Every updateInterval the job advances to the next row of its ranges, a change to a cell of the rows
+// currently shown is sent to the template right away.
+func NewUpdate(upstreamCtx context.Context, logger zerolog.Logger, eventProcessor types.EventProcessor, template string, layer int, videoChannels []int, casparCGClient types.CasparCGClient, casparMaps map[string]*Resolver, format func(data map[string]any) map[string]any, updateInterval time.Duration) types.UpdateJob {
 	ctx, cancel := context.WithCancel(upstreamCtx)
 	return &Update{
 		logger: logger.With().Str("component", "update").Str("template", template).Logger(),
 
+		eventProcessor: eventProcessor,
+
 		template:      template,
 		layer:         layer,
 		videoChannels: videoChannels,
 
 		casparCGClient: casparCGClient,
 		casparMaps:     casparMaps,
+		format:         format,
 
 		updateInterval: updateInterval,
 
@@ -82,27 +194,33 @@ func NewUpdate(upstreamCtx context.Context, logger zerolog.Logger, template stri
 }
 
 func (u *Update) Start() error {
+	// the listener has to keep draining until the processor released the channel, it only flags a refresh
+	refresh := make(chan struct{}, 1)
+	u.events = u.eventProcessor.Listen()
 	u.wg.Go(func() {
+		for event := range u.events {
+			update, ok := event.(types.DataSourceValueUpdate)
+			if !ok || !u.shows(update) {
+				continue
+			}
+			select {
+			case refresh <- struct{}{}:
+			default:
+			}
+		}
+	})
+
+	u.wg.Go(func() {
+		next := time.After(u.updateInterval)
 		for {
 			select {
 			case <-u.ctx.Done():
 				return
-			case <-time.After(u.updateInterval):
-				casparData := make(map[string]any)
-				for casparKey, resolver := range u.casparMaps {
-					value, err := resolver.GetData()
-					if err != nil {
-						u.logger.Error().Err(err).Str("casparKey", casparKey).Msg("Failed to get data from datasource")
-					}
-					casparData[casparKey] = value
-
-					resolver.Advance()
-				}
-
-				err := u.casparCGClient.UpdateCGData(u.template, u.layer, u.videoChannels, casparData)
-				if err != nil {
-					u.logger.Error().Err(err).Msg("Failed to update CG data")
-				}
+			case <-refresh:
+				u.send(false)
+			case <-next:
+				next = time.After(u.updateInterval)
+				u.send(true)
 			}
 		}
 	})
@@ -111,14 +229,56 @@ func (u *Update) Start() error {
 }
 
 func (u *Update) Stop() {
+	u.eventProcessor.CloseChannel(u.events)
 	u.cancel()
 	u.wg.Wait()
 }
 
+// shows reports whether a resolver currently shows the changed value
+func (u *Update) shows(update types.DataSourceValueUpdate) bool {
+	u.mtx.Lock()
+	defer u.mtx.Unlock()
+
+	for _, resolver := range u.casparMaps {
+		if resolver.Shows(update.Source, update.LocationKey) {
+			return true
+		}
+	}
+	return false
+}
+
+// send resolves the ranges and updates the template, advance moves on to the next rows, otherwise the
+// rows currently shown are read again
+func (u *Update) send(advance bool) {
+	u.mtx.Lock()
+	casparData := make(map[string]any)
+	for casparKey, resolver := range u.casparMaps {
+		var value any
+		var err error
+		if advance {
+			value, err = resolver.GetData()
+			resolver.Advance()
+		} else {
+			value, err = resolver.Current()
+		}
+		if err != nil {
+			u.logger.Error().Err(err).Str("casparKey", casparKey).Msg("Failed to get data from datasource")
+		}
+		casparData[casparKey] = value
+	}
+	u.mtx.Unlock()
+
+	err := u.casparCGClient.UpdateCGData(u.template, u.layer, u.videoChannels, u.format(casparData))
+	if err != nil {
+		u.logger.Error().Err(err).Msg("Failed to update CG data")
+	}
+}
+
 type UpdateHandler struct {
 	logger zerolog.Logger
 
 	datasourceManager types.DatasourceManager
+	eventProcessor    types.EventProcessor
 	casparCGClients   types.CasparCGClient
 
 	cycles map[string]types.UpdateJob
@@ -127,12 +287,13 @@ type UpdateHandler struct {
 	cancel context.CancelFunc
 }
 
-func NewUpdateHandler(upstreamCtx context.Context, logger zerolog.Logger, datasourceManager types.DatasourceManager, casparCGClients types.CasparCGClient) *UpdateHandler {
+func NewUpdateHandler(upstreamCtx context.Context, logger zerolog.Logger, datasourceManager types.DatasourceManager, eventProcessor types.EventProcessor, casparCGClients types.CasparCGClient) *UpdateHandler {
 	ctx, cancel := context.WithCancel(upstreamCtx)
 	return &UpdateHandler{
 		logger: logger.With().Str("component", "update-handler").Logger(),
 
 		datasourceManager: datasourceManager,
+		eventProcessor:    eventProcessor,
 		casparCGClients:   casparCGClients,
 
 		cycles: make(map[string]types.UpdateJob),
@@ -142,12 +303,13 @@ func NewUpdateHandler(upstreamCtx context.Context, logger zerolog.Logger, dataso
 	}
 }
 
-func (u *UpdateHandler) AddUpdateJob(template string, layer int, videoChannels []int, casparCGClient types.CasparCGClient, casparMaps map[string]*Resolver, updateInterval time.Duration) (uuid string) {
+func (u *UpdateHandler) AddUpdateJob(template string, layer int, videoChannels []int, casparCGClient types.CasparCGClient, casparMaps map[string]*Resolver, format func(data map[string]any) map[string]any, updateInterval time.Duration) (uuid string) {
 	uuid = guuid.NewString()
 	u.logger.Debug().Str("uuid", uuid).Msg("Adding update job")
 
-	job := NewUpdate(u.ctx, u.logger, template, layer, videoChannels, casparCGClient, casparMaps, updateInterval)
+	job := NewUpdate(u.ctx, u.logger, u.eventProcessor, template, layer, videoChannels, casparCGClient, casparMaps, format, updateInterval)
 	u.cycles[uuid] = job
+	setOnAir(casparMaps, true)
 	job.Start()
 
 	return uuid
@@ -159,7 +321,33 @@ func (u *UpdateHandler) RemoveUpdateJob(uuid string) error {
 	if job, ok := u.cycles[uuid]; ok {
 		job.Stop()
 		delete(u.cycles, uuid)
+		if update, ok := job.(*Update); ok {
+			setOnAir(update.casparMaps, false)
+			unsubscribe(u.logger, update.casparMaps)
+		}
 		return nil
 	}
 	return errors.New("update job not found")
 }
+
+// setOnAir marks the polling datasources read by an update job as on air, so they poll faster while it runs
+func setOnAir(casparMaps map[string]*Resolver, onAir bool) {
+	marked := make(map[types.PollingDataSource]bool)
+	for _, resolver := range casparMaps {
+		ds, ok := resolver.datasource.(types.PollingDataSource)
+		if !ok || marked[ds] {
+			continue
+		}
+		marked[ds] = true
+		ds.SetOnAir(onAir)
+	}
+}
+
+// unsubscribe releases the locations subscribed by the resolvers of an update job
+func unsubscribe(logger zerolog.Logger, casparMaps map[string]*Resolver) {
+	for casparKey, resolver := range casparMaps {
+		if err := resolver.Unsubscribe(); err != nil {
+			logger.Warn().Err(err).Str("casparKey", casparKey).Msg("Failed to unsubscribe from datasource")
+		}
+	}
+}